@@ -0,0 +1,74 @@
+package main
+
+import (
+	"GoNews/pkg/auth"
+	"GoNews/pkg/storage"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// apiKeyFlags - действия с ключами API, заданные флагами командной строки.
+type apiKeyFlags struct {
+	create string // имя выпускаемого ключа
+	scopes string // разрешения выпускаемого ключа через запятую
+	role   string // роль выпускаемого ключа
+	author int    // ID автора, от имени которого действует выпускаемый ключ
+	revoke int    // ID отзываемого ключа
+	list   bool   // вывести список ключей
+}
+
+// empty сообщает, что действий с ключами не задано.
+func (f apiKeyFlags) empty() bool {
+	return f.create == "" && f.revoke == 0 && !f.list
+}
+
+// manageAPIKeys выполняет действия с ключами API и выводит результат.
+// Выпущенный ключ выводится один раз: в хранилище сохраняется только его хеш.
+func manageAPIKeys(db storage.Interface, f apiKeyFlags) error {
+	ctx := context.Background()
+
+	if f.create != "" {
+		scopes, err := auth.ParseScopes(f.scopes)
+		if err != nil {
+			return err
+		}
+		key, k, err := auth.IssueAPIKey(ctx, db, storage.APIKey{Name: f.create, Scopes: scopes, Role: f.role, AuthorID: f.author})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Выпущен ключ API %d %q (%s, %s):\n%s\n", k.ID, k.Name, strings.Join(k.Scopes, ","), keyRole(k), key)
+	}
+
+	if f.revoke != 0 {
+		if err := db.RevokeAPIKey(ctx, f.revoke, time.Now().Unix()); err != nil {
+			return err
+		}
+		fmt.Printf("Ключ API %d отозван.\n", f.revoke)
+	}
+
+	if f.list {
+		keys, err := db.APIKeys(ctx)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			state := "действует"
+			if k.RevokedAt != 0 {
+				state = "отозван " + time.Unix(k.RevokedAt, 0).Format(time.RFC3339)
+			}
+			fmt.Printf("%d\t%s\t%s\t%s\t%s\n", k.ID, k.Name, strings.Join(k.Scopes, ","), keyRole(k), state)
+		}
+	}
+
+	return nil
+}
+
+// keyRole описывает роль ключа и автора, от имени которого он действует.
+func keyRole(k storage.APIKey) string {
+	if k.AuthorID == 0 {
+		return k.Role
+	}
+	return fmt.Sprintf("%s, автор %d", k.Role, k.AuthorID)
+}
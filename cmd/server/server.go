@@ -2,14 +2,22 @@ package main
 
 import (
 	"GoNews/pkg/api"
+	"GoNews/pkg/auth"
+	"GoNews/pkg/scheduler"
 	"GoNews/pkg/storage"
 	"GoNews/pkg/storage/memdb"
 	"GoNews/pkg/storage/mongodb"
 	"GoNews/pkg/storage/postgres"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"GoNews/config"
 
@@ -18,10 +26,14 @@ import (
 
 // Сервер GoNews.
 type server struct {
-	db  storage.Interface
-	api *api.API
+	db        storage.Interface
+	api       *api.API
+	scheduler *scheduler.Scheduler
 }
 
+// shutdownTimeout - время на завершение обработки запросов при остановке сервера.
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 
 	// Загрузка конфигурации с помощью Viper
@@ -33,12 +45,49 @@ func main() {
 	var srv server
 
 	migrate := flag.Bool("migrate", false, "Run database migrations")
+	rollback := flag.Int("rollback", -1, "Roll back PostgreSQL migrations down to the given version (0 - all) and exit")
+	migrateStatus := flag.Bool("migrate-status", false, "Show PostgreSQL migration status and exit")
 	seed := flag.Bool("seed", false, "Seed the database with initial data") // Флаг для сидирования
 	dbType := flag.String("db", "memdb", "Specify the database type: postgres, memdb, mongodb")
+	var keys apiKeyFlags
+	flag.StringVar(&keys.create, "apikey-create", "", "Issue an API key with the given name, print it and exit (memdb: keep running)")
+	flag.StringVar(&keys.scopes, "apikey-scopes", auth.ScopeRead, "Comma-separated scopes of the issued API key: read, write, admin")
+	flag.StringVar(&keys.role, "apikey-role", auth.RoleAuthor, "Role of the issued API key: author, editor, admin")
+	flag.IntVar(&keys.author, "apikey-author", 0, "ID of the author the issued API key acts as (required for role author to change posts)")
+	flag.IntVar(&keys.revoke, "apikey-revoke", 0, "Revoke the API key with the given ID and exit (memdb: keep running)")
+	flag.BoolVar(&keys.list, "apikey-list", false, "List API keys and exit (memdb: keep running)")
 	flag.Parse()
 
+	// Откат и состояние миграций поддерживает только PostgreSQL.
+	// С другими хранилищами сервер не запускается, чтобы флаг не был проигнорирован незаметно.
+	if *dbType != "postgres" && (*rollback >= 0 || *migrateStatus) {
+		log.Fatalf("Флаги -rollback и -migrate-status поддерживаются только с -db=postgres")
+	}
+
 	switch *dbType {
 	case "postgres":
+		if *migrateStatus {
+			status, err := postgres.Status(cfg.GetPostgresDSN())
+			if err != nil {
+				log.Fatalf("Ошибка при получении состояния миграций: %v", err)
+			}
+			for _, m := range status {
+				applied := "не применена"
+				if m.AppliedAt != 0 {
+					applied = "применена " + time.Unix(m.AppliedAt, 0).Format(time.RFC3339)
+				}
+				fmt.Printf("%04d_%s\t%s\n", m.Version, m.Name, applied)
+			}
+			return
+		}
+		if *rollback >= 0 {
+			fmt.Printf("Откат миграций PostgreSQL до версии %d...\n", *rollback)
+			if err := postgres.Rollback(cfg.GetPostgresDSN(), *rollback); err != nil {
+				log.Fatalf("Ошибка при откате миграций: %v", err)
+			}
+			fmt.Println("Откат завершён.")
+			return
+		}
 		if *migrate {
 			fmt.Println("Запуск миграции для PostgreSQL...")
 			err := postgres.Migrate(cfg.GetPostgresDSN())
@@ -47,10 +96,18 @@ func main() {
 			}
 			fmt.Println("Миграция завершена.")
 		}
-		srv.db, err = postgres.New(cfg.GetPostgresDSN())
+		pgDB, err := postgres.New(cfg.GetPostgresDSN())
 		if err != nil {
 			log.Fatalf("Ошибка при инициализации базы данных PostgreSQL: %v", err)
 		}
+		if *seed {
+			fmt.Println("Запуск сидирования для PostgreSQL...")
+			if err := postgres.SeedPosts(context.Background(), pgDB); err != nil {
+				log.Fatalf("Ошибка при сидировании базы данных: %v", err)
+			}
+			fmt.Println("Сидирование завершено.")
+		}
+		srv.db = pgDB
 
 	case "memdb":
 		srv.db = memdb.New()
@@ -62,6 +119,14 @@ func main() {
 		}
 		defer mongoDB.Close()
 
+		if *migrate {
+			fmt.Println("Запуск миграции для MongoDB...")
+			if err := mongoDB.Migrate(); err != nil {
+				log.Fatalf("Ошибка при выполнении миграции: %v", err)
+			}
+			fmt.Println("Миграция завершена.")
+		}
+
 		if *seed {
 			// Сначала проверим, нужно ли сидировать
 			fmt.Println("Запуск сидирования для MongoDB...")
@@ -78,8 +143,26 @@ func main() {
 		log.Fatalf("Неизвестный тип базы данных: %s", cfg.Database.Type)
 	}
 
+	// Ключи API в memdb живут только в памяти процесса,
+	// поэтому с memdb сервер продолжает работу после выпуска ключа.
+	if !keys.empty() {
+		if err := manageAPIKeys(srv.db, keys); err != nil {
+			log.Fatalf("Ошибка при работе с ключами API: %v", err)
+		}
+		if *dbType != "memdb" {
+			return
+		}
+	}
+
 	// Создаём объект API и регистрируем обработчики.
-	srv.api = api.New(srv.db)
+	srv.api, err = api.New(srv.db, cfg)
+	if err != nil {
+		log.Fatalf("Ошибка при инициализации API: %v", err)
+	}
+	// Отправка писем не реализована: токен сброса пароля выводится в журнал сервера.
+	srv.api.OnPasswordReset(func(u storage.User, token string) {
+		fmt.Printf("Токен сброса пароля для %s: %s\n", u.Email, token)
+	})
 
 	// Запускаем веб-сервер на порту 8080 на всех интерфейсах.
 	// Предаём серверу маршрутизатор запросов,
@@ -89,5 +172,35 @@ func main() {
 	port := viper.GetInt("server.port")
 	addr := fmt.Sprintf(":%d", port)
 
-	http.ListenAndServe(addr, srv.api.Router())
+	// Запускаем публикацию запланированных публикаций и очистку корзины.
+	srv.scheduler = scheduler.New(srv.db, scheduler.Options{
+		PublishInterval: cfg.Scheduler.Interval,
+		PurgeInterval:   cfg.Scheduler.PurgeInterval,
+		TrashRetention:  cfg.Trash.Retention,
+	})
+	srv.scheduler.OnPublish(func(p storage.Post) {
+		fmt.Printf("Опубликована публикация %d %q.\n", p.ID, p.Title)
+	})
+	srv.scheduler.Start()
+
+	httpServer := &http.Server{Addr: addr, Handler: srv.api.Router()}
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Ошибка веб-сервера: %v", err)
+		}
+	}()
+
+	// Ждём сигнала остановки, затем завершаем обработку запросов и фоновые задачи.
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+	fmt.Println("Остановка сервера...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		fmt.Printf("Ошибка при остановке веб-сервера: %v\n", err)
+	}
+	srv.scheduler.Stop()
+	fmt.Println("Сервер остановлен.")
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -9,8 +10,10 @@ import (
 // Config структура для конфигурации
 type Config struct {
 	Server struct {
-		Port int    `mapstructure:"port"`
-		Host string `mapstructure:"host"`
+		Port           int           `mapstructure:"port"`
+		Host           string        `mapstructure:"host"`
+		RequestTimeout time.Duration `mapstructure:"request_timeout"` // Предельное время обработки запроса, 0 - без ограничения
+		BaseURL        string        `mapstructure:"base_url"`        // Публичный адрес сервера для ссылок в лентах и картах сайта, пустой - адрес из запроса
 	} `mapstructure:"server"`
 	Database struct {
 		Type     string         `mapstructure:"type"` // Тип базы данных
@@ -18,6 +21,40 @@ type Config struct {
 		MongoDB  MongoDBConfig  `mapstructure:"mongodb"`
 		MemDB    struct{}       `mapstructure:"memdb"` // Пустая структура для memdb
 	} `mapstructure:"database"`
+	Scheduler struct {
+		Interval      time.Duration `mapstructure:"interval"`       // Период публикации запланированных публикаций, 0 - отключить
+		PurgeInterval time.Duration `mapstructure:"purge_interval"` // Период очистки корзины, 0 - отключить
+	} `mapstructure:"scheduler"`
+	Trash struct {
+		Retention time.Duration `mapstructure:"retention"` // Срок хранения удалённых публикаций, 0 - хранить бессрочно
+	} `mapstructure:"trash"`
+	Cache struct {
+		MaxAge time.Duration `mapstructure:"max_age"` // Время кеширования публичных ответов (Cache-Control: max-age), 0 - проверять каждый раз
+	} `mapstructure:"cache"`
+	Auth struct {
+		JWT struct {
+			Secret        string        `mapstructure:"hs256_secret"`     // Общий секрет HS256, пустой - HS256 не принимается
+			PublicKeyFile string        `mapstructure:"rs256_public_key"` // Путь к открытому ключу RS256 в формате PEM, пустой - RS256 не принимается
+			Issuer        string        `mapstructure:"issuer"`           // Ожидаемый издатель (iss), пустой - не проверяется
+			Audience      string        `mapstructure:"audience"`         // Ожидаемый получатель (aud), пустой - не проверяется
+			Leeway        time.Duration `mapstructure:"leeway"`           // Допустимое расхождение часов при проверке exp и nbf
+			TokenTTL      time.Duration `mapstructure:"token_ttl"`        // Время действия токенов, выдаваемых при входе пользователя
+		} `mapstructure:"jwt"`
+		Users struct {
+			Registration bool          `mapstructure:"registration"` // Разрешена ли самостоятельная регистрация пользователей
+			ResetTTL     time.Duration `mapstructure:"reset_ttl"`    // Время действия токена сброса пароля
+		} `mapstructure:"users"`
+		OIDC struct {
+			Issuer       string            `mapstructure:"issuer"`        // Адрес провайдера OpenID Connect, пустой - вход через OIDC отключён
+			ClientID     string            `mapstructure:"client_id"`     // Идентификатор клиента у провайдера
+			ClientSecret string            `mapstructure:"client_secret"` // Секрет клиента, пустой - публичный клиент
+			RedirectURL  string            `mapstructure:"redirect_url"`  // Адрес возврата, пустой - server.base_url + /auth/oidc/callback
+			Scopes       []string          `mapstructure:"scopes"`        // Запрашиваемые области кроме openid
+			RoleClaim    string            `mapstructure:"role_claim"`    // Поле ID-токена с группами пользователя
+			Roles        map[string]string `mapstructure:"roles"`         // Роли для групп из role_claim (имена групп в нижнем регистре)
+			DefaultRole  string            `mapstructure:"default_role"`  // Роль пользователя без подходящих групп, пустая - вход запрещён
+		} `mapstructure:"oidc"`
+	} `mapstructure:"auth"`
 }
 
 // PostgresConfig структура для конфигурации PostgreSQL
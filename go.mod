@@ -1,11 +1,12 @@
 module GoNews
 
-go 1.15
+go 1.16
 
 require (
 	github.com/gorilla/mux v1.8.1
+	github.com/jackc/pgconn v1.14.3
 	github.com/jackc/pgx/v4 v4.18.3
 	github.com/spf13/viper v1.19.0
-	go.mongodb.org/mongo-driver v1.17.1 
-	golang.org/x/crypto v0.26.0 // indirect
+	go.mongodb.org/mongo-driver v1.17.1
+	golang.org/x/crypto v0.26.0
 )
@@ -1,9 +1,17 @@
 package api
 
 import (
+	"GoNews/config"
+	"GoNews/pkg/auth"
+	"GoNews/pkg/oidc"
 	"GoNews/pkg/storage"
+	"context"
 	"encoding/json"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -11,7 +19,12 @@ import (
 // Программный интерфейс сервера GoNews
 type API struct {
 	db     storage.Interface
+	cfg    config.Config
+	jwt    *auth.JWT
+	oidc   *oidc.Client // nil - вход через OpenID Connect не настроен
 	router *mux.Router
+
+	onPasswordReset func(u storage.User, token string) // доставка токена сброса пароля
 }
 
 type ErrorResponse struct {
@@ -19,21 +32,62 @@ type ErrorResponse struct {
 }
 
 // Конструктор объекта API
-func New(db storage.Interface) *API {
+func New(db storage.Interface, cfg config.Config) (*API, error) {
+	jwt, err := newJWT(cfg)
+	if err != nil {
+		return nil, err
+	}
+	oidcClient, err := newOIDC(cfg)
+	if err != nil {
+		return nil, err
+	}
 	api := API{
-		db: db,
+		db:   db,
+		cfg:  cfg,
+		jwt:  jwt,
+		oidc: oidcClient,
 	}
 	api.router = mux.NewRouter()
+	api.router.Use(api.timeoutMiddleware)
+	api.router.Use(api.authMiddleware)
 	api.endpoints()
-	return &api
+	return &api, nil
+}
+
+// timeoutMiddleware ограничивает время обработки запроса значением
+// server.request_timeout из конфигурации. Контекст запроса передаётся
+// в хранилище, поэтому отмена запроса клиентом или истечение времени
+// прерывают обращение к БД.
+func (api *API) timeoutMiddleware(next http.Handler) http.Handler {
+	timeout := api.cfg.Server.RequestTimeout
+	if timeout <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
 }
 
 // Регистрация обработчиков API.
 func (api *API) endpoints() {
 	api.router.HandleFunc("/posts", api.postsHandler).Methods(http.MethodGet, http.MethodOptions)
+	api.router.HandleFunc("/posts/search", api.searchHandler).Methods(http.MethodGet, http.MethodOptions)
+	api.router.HandleFunc("/posts/{id:[0-9]+}", api.postHandler).Methods(http.MethodGet, http.MethodOptions)
 	api.router.HandleFunc("/posts", api.addPostHandler).Methods(http.MethodPost, http.MethodOptions)
 	api.router.HandleFunc("/posts", api.updatePostHandler).Methods(http.MethodPut, http.MethodOptions)
 	api.router.HandleFunc("/posts", api.deletePostHandler).Methods(http.MethodDelete, http.MethodOptions)
+	api.router.HandleFunc("/posts/{id:[0-9]+}/publish", requireScope(auth.ScopeWrite, api.publishPostHandler)).Methods(http.MethodPost, http.MethodOptions)
+	api.router.HandleFunc("/posts/{id:[0-9]+}/restore", requireScope(auth.ScopeWrite, api.restorePostHandler)).Methods(http.MethodPost, http.MethodOptions)
+	api.router.HandleFunc("/trash", requireScope(auth.ScopeRead, api.trashHandler)).Methods(http.MethodGet, http.MethodOptions)
+	api.authorEndpoints()
+	api.revisionEndpoints()
+	api.feedEndpoints()
+	api.sitemapEndpoints()
+	api.apiKeyEndpoints()
+	api.userEndpoints()
+	api.oidcEndpoints()
 }
 
 // Получение маршрутизатора запросов.
@@ -68,82 +122,358 @@ func (api *API) validatePostUpdate(p storage.Post) []string {
 	return validationErrors
 }
 
-// Получение всех публикаций.
+// visibleFilter ограничивает отбор опубликованными публикациями,
+// если запрос выполняется без аутентификации.
+func (api *API) visibleFilter(r *http.Request, f *storage.PostFilter) {
+	if !authenticated(r) {
+		f.Status = storage.StatusPublished
+	}
+}
+
+// Получение страницы публикаций.
+// Параметры limit и offset задают размер и смещение страницы,
+// общее количество и ссылки на соседние страницы передаются в заголовках.
+// При наличии параметра cursor используется постраничное чтение по курсору.
+// Ответ можно запросить условно, см. writeCached.
+// Условия отбора и сортировка задаются параметрами, см. parsePostFilter.
+// Без аутентификации возвращаются только опубликованные публикации.
 func (api *API) postsHandler(w http.ResponseWriter, r *http.Request) {
-	posts, err := api.db.Posts()
+	if _, ok := r.URL.Query()["cursor"]; ok {
+		api.postsByCursorHandler(w, r)
+		return
+	}
+
+	filter, validationErrors := parsePostFilter(r.URL.Query())
+	limit, offset, pageErrors := parsePage(r.URL.Query())
+	validationErrors = append(validationErrors, pageErrors...)
+	if len(validationErrors) > 0 {
+		writeErrors(w, http.StatusBadRequest, validationErrors...)
+		return
+	}
+
+	api.visibleFilter(r, &filter)
+	posts, total, err := api.db.Posts(r.Context(), filter, limit, offset)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if posts == nil {
+		posts = []storage.Post{}
+	}
+	bytes, err := json.Marshal(posts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	setPageHeaders(w, r, limit, offset, total)
+	api.writeCached(w, r, bytes, "", lastModified(posts...))
+}
+
+// Получение публикаций по курсору в порядке убывания даты создания.
+// Токен следующей страницы передаётся в заголовках X-Next-Cursor и Link.
+func (api *API) postsByCursorHandler(w http.ResponseWriter, r *http.Request) {
+	filter, validationErrors := parsePostFilter(r.URL.Query())
+	cursor, limit, pageErrors := parseCursorPage(r.URL.Query())
+	validationErrors = append(validationErrors, pageErrors...)
+	if len(validationErrors) > 0 {
+		writeErrors(w, http.StatusBadRequest, validationErrors...)
+		return
+	}
+
+	api.visibleFilter(r, &filter)
+	// Запрашиваем на одну публикацию больше, чтобы узнать, есть ли следующая страница.
+	posts, err := api.db.PostsAfter(r.Context(), filter, cursor, limit+1)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
+	if posts == nil {
+		posts = []storage.Post{}
+	}
+	if len(posts) > limit {
+		posts = posts[:limit]
+		last := posts[len(posts)-1]
+		setCursorHeaders(w, r, encodeCursor(storage.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}))
+	}
 	bytes, err := json.Marshal(posts)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
-	w.Write(bytes)
+	api.writeCached(w, r, bytes, "", lastModified(posts...))
+}
+
+// Полнотекстовый поиск публикаций по заголовку и содержанию.
+// Параметр q задаёт поисковый запрос, limit и offset - страницу результатов,
+// остальные параметры - условия отбора, см. parsePostFilter.
+func (api *API) searchHandler(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	filter, validationErrors := parsePostFilter(r.URL.Query())
+	limit, offset, pageErrors := parsePage(r.URL.Query())
+	validationErrors = append(validationErrors, pageErrors...)
+	if query == "" {
+		validationErrors = append(validationErrors, "поисковый запрос не может быть пустым")
+	}
+	if len(validationErrors) > 0 {
+		writeErrors(w, http.StatusBadRequest, validationErrors...)
+		return
+	}
+
+	api.visibleFilter(r, &filter)
+	results, err := api.db.Search(r.Context(), filter, query, limit, offset)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if results == nil {
+		results = []storage.SearchResult{}
+	}
+	var modified int64
+	for _, res := range results {
+		if m := lastModified(res.Post); m > modified {
+			modified = m
+		}
+	}
+	bytes, err := json.Marshal(results)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	api.writeCached(w, r, bytes, "", modified)
+}
+
+// Получение публикации по ID.
+// Неопубликованная публикация доступна только после аутентификации.
+// Версия публикации передаётся в заголовке ETag, момент её последнего
+// изменения - в Last-Modified.
+func (api *API) postHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	post, err := api.db.Post(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if post.Status != storage.StatusPublished && !authenticated(r) {
+		writeErrors(w, http.StatusNotFound, "публикация не найдена")
+		return
+	}
+	bytes, err := json.Marshal(post)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	api.writeCached(w, r, bytes, etag(post.Version), lastModified(post))
 }
 
 // Добавление публикации.
+// Новая публикация всегда создаётся черновиком, см. publishPostHandler.
+// Права проверяются правилами из policy.go.
 func (api *API) addPostHandler(w http.ResponseWriter, r *http.Request) {
 	var p storage.Post
 	err := json.NewDecoder(r.Body).Decode(&p)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeErrors(w, http.StatusBadRequest, "некорректное тело запроса: "+err.Error())
 		return
 	}
 
 	validationErrors := api.validatePost(p)
 	if len(validationErrors) > 0 {
-		response := ErrorResponse{Errors: validationErrors}
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(response)
+		writeErrors(w, http.StatusBadRequest, validationErrors...)
+		return
+	}
+	if !canCreatePost(principal(r), p) {
+		forbidden(w, "автор может создавать только свои публикации")
 		return
 	}
+	p.Status = storage.StatusDraft
+	p.PublishedAt = 0
+	p.DeletedAt = 0
+	// Моменты создания и изменения проставляет хранилище, а не клиент.
+	p.CreatedAt = 0
+	p.UpdatedAt = 0
 
-	err = api.db.AddPost(p)
+	err = api.db.AddPost(r.Context(), p)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
 }
 
 // Обновление публикации.
+// Состояние и момент публикации этим запросом не меняются.
+// Права проверяются правилами из policy.go.
+// Заголовок If-Match должен содержать ETag изменяемой версии публикации.
 func (api *API) updatePostHandler(w http.ResponseWriter, r *http.Request) {
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
 	var p storage.Post
 	err := json.NewDecoder(r.Body).Decode(&p)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeErrors(w, http.StatusBadRequest, "некорректное тело запроса: "+err.Error())
 		return
 	}
+	p.Version = version
 
 	validationErrors := api.validatePostUpdate(p)
 	if len(validationErrors) > 0 {
-		response := ErrorResponse{Errors: validationErrors}
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(response)
+		writeErrors(w, http.StatusBadRequest, validationErrors...)
+		return
+	}
+	p.Status = ""
+	p.PublishedAt = 0
+	p.DeletedAt = 0
+	p.CreatedAt = 0
+	p.UpdatedAt = 0
+
+	current, err := api.db.Post(r.Context(), p.ID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if !canUpdatePost(principal(r), current, p) {
+		forbidden(w, "автор может изменять только свои публикации")
 		return
 	}
+	// Права проверены для прочитанной версии: если публикацию успели
+	// изменить, например передать другому автору, обновление не выполнится.
+	if p.Version == 0 {
+		p.Version = current.Version
+	}
 
-	err = api.db.UpdatePost(p)
+	err = api.db.UpdatePost(r.Context(), p)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
 }
 
 // Удаление публикации.
+// Публикация перемещается в корзину, её можно восстановить, см. restorePostHandler.
+// Права проверяются правилами из policy.go.
+// Заголовок If-Match должен содержать ETag удаляемой версии публикации.
 func (api *API) deletePostHandler(w http.ResponseWriter, r *http.Request) {
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
 	var p storage.Post
 	err := json.NewDecoder(r.Body).Decode(&p)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeErrors(w, http.StatusBadRequest, "некорректное тело запроса: "+err.Error())
+		return
+	}
+	p.Version = version
+
+	current, err := api.db.Post(r.Context(), p.ID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if !canEditPost(principal(r), current) {
+		forbidden(w, "автор может удалять только свои публикации")
+		return
+	}
+	if p.Version == 0 {
+		p.Version = current.Version
+	}
+
+	err = api.db.DeletePost(r.Context(), p)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// publishRequest - тело запроса на публикацию.
+type publishRequest struct {
+	PublishedAt int64 // Момент публикации, 0 - немедленно
+}
+
+// Публикация черновика.
+// Если PublishedAt в будущем, публикация становится запланированной.
+// Тело запроса необязательно: без него публикация выполняется немедленно.
+func (api *API) publishPostHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	if !canPublishPost(principal(r)) {
+		forbidden(w, "публиковать может только редактор")
+		return
+	}
+
+	var req publishRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		writeErrors(w, http.StatusBadRequest, "некорректное тело запроса: "+err.Error())
+		return
+	}
+	if req.PublishedAt < 0 {
+		writeErrors(w, http.StatusBadRequest, "момент публикации не может быть отрицательным")
+		return
+	}
+	if req.PublishedAt == 0 {
+		req.PublishedAt = time.Now().Unix()
+	}
+
+	err = api.db.PublishPost(r.Context(), id, req.PublishedAt)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Получение страницы публикаций из корзины.
+// Параметры те же, что у списка публикаций; по умолчанию сначала
+// показываются последние удалённые публикации.
+func (api *API) trashHandler(w http.ResponseWriter, r *http.Request) {
+	filter, validationErrors := parsePostFilter(r.URL.Query())
+	limit, offset, pageErrors := parsePage(r.URL.Query())
+	validationErrors = append(validationErrors, pageErrors...)
+	if len(validationErrors) > 0 {
+		writeErrors(w, http.StatusBadRequest, validationErrors...)
 		return
 	}
-	err = api.db.DeletePost(p)
+	filter.Deleted = true
+	if filter.SortBy == "" {
+		filter.SortBy = storage.SortByDeletedAt
+		filter.SortDesc = true
+	}
+
+	posts, total, err := api.db.Posts(r.Context(), filter, limit, offset)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if posts == nil {
+		posts = []storage.Post{}
+	}
+	bytes, err := json.Marshal(posts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	setPageHeaders(w, r, limit, offset, total)
+	api.writeCached(w, r, bytes, "", lastModified(posts...))
+}
+
+// Восстановление публикации из корзины.
+func (api *API) restorePostHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	if !canRestorePost(principal(r)) {
+		forbidden(w, "восстанавливать публикации может только редактор")
+		return
+	}
+
+	err := api.db.RestorePost(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"GoNews/pkg/auth"
+	"GoNews/pkg/storage"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Регистрация обработчиков управления ключами API.
+// Все они требуют разрешения admin и роли admin, см. canManageAPIKeys.
+func (api *API) apiKeyEndpoints() {
+	api.router.HandleFunc("/apikeys", requireScope(auth.ScopeAdmin, requirePolicy(canManageAPIKeys, apiKeysForbidden, api.apiKeysHandler))).Methods(http.MethodGet, http.MethodOptions)
+	api.router.HandleFunc("/apikeys", requireScope(auth.ScopeAdmin, requirePolicy(canManageAPIKeys, apiKeysForbidden, api.addAPIKeyHandler))).Methods(http.MethodPost, http.MethodOptions)
+	api.router.HandleFunc("/apikeys/{id:[0-9]+}", requireScope(auth.ScopeAdmin, requirePolicy(canManageAPIKeys, apiKeysForbidden, api.revokeAPIKeyHandler))).Methods(http.MethodDelete, http.MethodOptions)
+}
+
+// apiKeysForbidden - описание ошибки для клиентов, которым нельзя управлять ключами API.
+const apiKeysForbidden = "управлять ключами API может только администратор"
+
+// apiKeyResponse - ключ API в ответе. Хеш ключа клиенту не передаётся.
+type apiKeyResponse struct {
+	ID        int
+	Name      string
+	Scopes    []string
+	Role      string
+	AuthorID  int
+	CreatedAt int64
+	RevokedAt int64
+	Key       string `json:",omitempty"` // сам ключ, только в ответе на выпуск
+}
+
+func newAPIKeyResponse(k storage.APIKey) apiKeyResponse {
+	return apiKeyResponse{ID: k.ID, Name: k.Name, Scopes: k.Scopes, Role: k.Role, AuthorID: k.AuthorID, CreatedAt: k.CreatedAt, RevokedAt: k.RevokedAt}
+}
+
+// Получение всех ключей API, в том числе отозванных.
+func (api *API) apiKeysHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := api.db.APIKeys(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	resp := make([]apiKeyResponse, 0, len(keys))
+	for _, k := range keys {
+		resp = append(resp, newAPIKeyResponse(k))
+	}
+	bytes, err := json.Marshal(resp)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	api.writeCached(w, r, bytes, "", 0)
+}
+
+// Выпуск ключа API. Тело запроса: {"Name": "...", "Scopes": ["write"], "Role": "author", "AuthorID": 1}.
+// Без Role ключ получает роль author, без AuthorID - не действует от имени автора,
+// см. auth.IssueAPIKey.
+// Ключ возвращается в ответе один раз, в хранилище сохраняется только его хеш.
+func (api *API) addAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name     string
+		Scopes   []string
+		Role     string
+		AuthorID int
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, "некорректное тело запроса: "+err.Error())
+		return
+	}
+
+	var validationErrors []string
+	if strings.TrimSpace(req.Name) == "" {
+		validationErrors = append(validationErrors, "имя ключа API не может быть пустым")
+	}
+	scopes, err := auth.ParseScopes(strings.Join(req.Scopes, ","))
+	if err != nil {
+		validationErrors = append(validationErrors, err.Error())
+	}
+	if req.Role != "" && !auth.ValidRole(req.Role) {
+		validationErrors = append(validationErrors, "неизвестная роль "+strconv.Quote(req.Role))
+	}
+	if len(validationErrors) > 0 {
+		writeErrors(w, http.StatusBadRequest, validationErrors...)
+		return
+	}
+
+	key, k, err := auth.IssueAPIKey(r.Context(), api.db, storage.APIKey{
+		Name:     req.Name,
+		Scopes:   scopes,
+		Role:     req.Role,
+		AuthorID: req.AuthorID,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	resp := newAPIKeyResponse(k)
+	resp.Key = key
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Отзыв ключа API. Повторный отзыв не является ошибкой.
+func (api *API) revokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	err := api.db.RevokeAPIKey(r.Context(), id, time.Now().Unix())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
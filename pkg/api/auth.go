@@ -0,0 +1,244 @@
+package api
+
+import (
+	"GoNews/config"
+	"GoNews/pkg/auth"
+	"GoNews/pkg/storage"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// newJWT создаёт объект проверки JWT по параметрам auth.jwt из конфигурации.
+// Слабый секрет HS256 считается ошибкой конфигурации, см. auth.CheckSecret.
+// Секрет обязателен при самостоятельной регистрации пользователей:
+// без него вошедшим пользователям нельзя выдать токен.
+func newJWT(cfg config.Config) (*auth.JWT, error) {
+	c := cfg.Auth.JWT
+	if c.Secret != "" {
+		if err := auth.CheckSecret(c.Secret); err != nil {
+			return nil, fmt.Errorf("auth.jwt.hs256_secret: %w", err)
+		}
+	} else if cfg.Auth.Users.Registration {
+		return nil, fmt.Errorf("для регистрации пользователей требуется auth.jwt.hs256_secret")
+	}
+	opts := auth.JWTOptions{
+		Secret:   []byte(c.Secret),
+		Issuer:   c.Issuer,
+		Audience: c.Audience,
+		Leeway:   c.Leeway,
+	}
+	if c.PublicKeyFile != "" {
+		data, err := os.ReadFile(c.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать открытый ключ RS256: %w", err)
+		}
+		opts.PublicKey, err = auth.ParseRSAPublicKey(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return auth.NewJWT(opts), nil
+}
+
+// Схемы заголовка Authorization.
+const (
+	schemeBearer = "Bearer" // JWT
+	schemeAPIKey = "ApiKey" // ключ API, см. apiKeyPrincipal
+)
+
+// credentials разбирает заголовок Authorization: <схема> <учётные данные>.
+// Схема сравнивается без учёта регистра и возвращается в каноническом виде.
+func credentials(r *http.Request) (scheme, value string, ok bool) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", "", false
+	}
+	scheme, value = header, ""
+	if i := strings.IndexByte(header, ' '); i >= 0 {
+		scheme, value = header[:i], strings.TrimSpace(header[i+1:])
+	}
+	for _, s := range []string{schemeBearer, schemeAPIKey} {
+		if strings.EqualFold(scheme, s) {
+			return s, value, true
+		}
+	}
+	return scheme, value, true
+}
+
+// apiKeyPrincipal возвращает клиента, которому выдан ключ API.
+// Неизвестный и отозванный ключи считаются ошибкой аутентификации.
+func (api *API) apiKeyPrincipal(ctx context.Context, key string) (auth.Principal, error) {
+	k, err := api.db.APIKeyByHash(ctx, auth.HashToken(key))
+	if errors.Is(err, storage.ErrNotFound) {
+		return auth.Principal{}, fmt.Errorf("%w: неизвестный ключ API", auth.ErrUnauthenticated)
+	}
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	if k.RevokedAt != 0 {
+		return auth.Principal{}, fmt.Errorf("%w: ключ API отозван", auth.ErrUnauthenticated)
+	}
+	// Роль и автор задаются при выпуске ключа: ключ с разрешением write
+	// подчиняется тем же правилам, что и пользователь с той же ролью.
+	return auth.Principal{
+		Subject:  fmt.Sprintf("apikey:%d", k.ID),
+		Name:     k.Name,
+		Scopes:   k.Scopes,
+		Role:     k.Role,
+		AuthorID: k.AuthorID,
+	}, nil
+}
+
+// jwtPrincipal проверяет JWT и возвращает клиента, которому он выдан.
+// Токен пользователя сервера действует, только пока версия в нём совпадает
+// с текущей версией учётных данных: смена и сброс пароля её увеличивают,
+// и украденный токен перестаёт действовать до истечения срока.
+// Токен удалённого пользователя тоже недействителен.
+func (api *API) jwtPrincipal(ctx context.Context, token string) (auth.Principal, error) {
+	p, err := api.jwt.Verify(token, time.Now())
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	id, ok := subjectUserID(p.Subject)
+	if !ok {
+		return p, nil
+	}
+	u, err := api.db.User(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return auth.Principal{}, fmt.Errorf("%w: пользователь не найден", auth.ErrUnauthenticated)
+	}
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	if u.TokenVersion != p.TokenVersion {
+		return auth.Principal{}, fmt.Errorf("%w: токен отозван сменой пароля", auth.ErrUnauthenticated)
+	}
+	return p, nil
+}
+
+// authenticate проверяет учётные данные из заголовка Authorization.
+func (api *API) authenticate(r *http.Request, scheme, value string) (auth.Principal, error) {
+	switch scheme {
+	case schemeBearer:
+		return api.jwtPrincipal(r.Context(), value)
+	case schemeAPIKey:
+		return api.apiKeyPrincipal(r.Context(), value)
+	default:
+		return auth.Principal{}, fmt.Errorf("%w: неподдерживаемая схема авторизации %q", auth.ErrUnauthenticated, scheme)
+	}
+}
+
+// safeMethod сообщает, что метод только читает данные
+// и доступен без аутентификации.
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// unauthorized отправляет ответ 401 с описанием ошибки аутентификации.
+// invalid сообщает, что клиент передал учётные данные, но они не прошли проверку.
+func unauthorized(w http.ResponseWriter, invalid bool, msg string) {
+	bearer := schemeBearer
+	if invalid {
+		bearer += ` error="invalid_token"`
+	}
+	w.Header().Add("WWW-Authenticate", bearer)
+	w.Header().Add("WWW-Authenticate", schemeAPIKey)
+	writeErrors(w, http.StatusUnauthorized, msg)
+}
+
+// forbidden отправляет ответ 403 с описанием недостающих прав.
+func forbidden(w http.ResponseWriter, msg string) {
+	writeErrors(w, http.StatusForbidden, "недостаточно прав: "+msg)
+}
+
+// authMiddleware проверяет учётные данные из заголовка Authorization
+// (Bearer <JWT> или ApiKey <ключ>) и передаёт аутентифицированного клиента
+// в контексте запроса, см. auth.PrincipalFrom. Его имя записывается
+// в ревизии публикаций, которые он изменяет.
+// Запросы GET и запросы из publicPaths доступны без аутентификации,
+// остальные требуют разрешения write.
+// Неверные или просроченные учётные данные отклоняются для любого метода.
+func (api *API) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, value, ok := credentials(r)
+		if !ok {
+			if !safeMethod(r.Method) && !publicPaths[r.URL.Path] {
+				unauthorized(w, false, "требуется аутентификация")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		p, err := api.authenticate(r, scheme, value)
+		if errors.Is(err, auth.ErrUnauthenticated) {
+			unauthorized(w, true, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if !safeMethod(r.Method) && !p.HasScope(auth.ScopeWrite) {
+			forbidden(w, "требуется разрешение "+auth.ScopeWrite)
+			return
+		}
+		ctx := auth.WithPrincipal(r.Context(), p)
+		ctx = storage.WithEditor(ctx, p.DisplayName())
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authenticated сообщает, что запрос выполняет клиент с разрешением read.
+// Такой клиент видит черновики и запланированные публикации.
+func authenticated(r *http.Request) bool {
+	p, ok := auth.PrincipalFrom(r.Context())
+	return ok && p.HasScope(auth.ScopeRead)
+}
+
+// requireScope пропускает к обработчику только запросы клиентов
+// с разрешением scope. Нужен для обработчиков GET, которые не открыты всем,
+// и для изменений, требующих разрешения шире write.
+func requireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := auth.PrincipalFrom(r.Context())
+		if !ok {
+			unauthorized(w, false, "требуется аутентификация")
+			return
+		}
+		if !p.HasScope(scope) {
+			forbidden(w, "требуется разрешение "+scope)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// principal возвращает клиента, выполняющего запрос.
+// Для запроса без аутентификации возвращается пустой Principal без ролей.
+func principal(r *http.Request) auth.Principal {
+	p, _ := auth.PrincipalFrom(r.Context())
+	return p
+}
+
+// requirePolicy пропускает к обработчику только запросы клиентов,
+// которым правило allowed разрешает действие, иначе отвечает 403 с msg.
+func requirePolicy(allowed func(auth.Principal) bool, msg string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !allowed(principal(r)) {
+			forbidden(w, msg)
+			return
+		}
+		next(w, r)
+	}
+}
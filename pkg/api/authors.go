@@ -0,0 +1,145 @@
+package api
+
+import (
+	"GoNews/pkg/storage"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Регистрация обработчиков для работы с авторами.
+// Добавлять и переименовывать авторов может только редактор, см. canManageAuthors,
+// удалять - только администратор, см. canDeleteAuthor.
+func (api *API) authorEndpoints() {
+	api.router.HandleFunc("/authors", api.authorsHandler).Methods(http.MethodGet, http.MethodOptions)
+	api.router.HandleFunc("/authors", requirePolicy(canManageAuthors, authorsForbidden, api.addAuthorHandler)).Methods(http.MethodPost, http.MethodOptions)
+	api.router.HandleFunc("/authors/{id:[0-9]+}", api.authorHandler).Methods(http.MethodGet, http.MethodOptions)
+	api.router.HandleFunc("/authors/{id:[0-9]+}", requirePolicy(canManageAuthors, authorsForbidden, api.updateAuthorHandler)).Methods(http.MethodPut, http.MethodOptions)
+	api.router.HandleFunc("/authors/{id:[0-9]+}", requirePolicy(canDeleteAuthor, deleteAuthorForbidden, api.deleteAuthorHandler)).Methods(http.MethodDelete, http.MethodOptions)
+}
+
+// authorsForbidden - описание ошибки для клиентов, которым нельзя управлять авторами.
+const authorsForbidden = "управлять авторами может только редактор"
+
+// deleteAuthorForbidden - описание ошибки для клиентов, которым нельзя удалять авторов.
+const deleteAuthorForbidden = "удалять авторов может только администратор"
+
+func (api *API) validateAuthor(a storage.Author) []string {
+	var validationErrors []string
+
+	if strings.TrimSpace(a.Name) == "" {
+		validationErrors = append(validationErrors, "имя автора не может быть пустым")
+	}
+
+	return validationErrors
+}
+
+// Получение всех авторов.
+func (api *API) authorsHandler(w http.ResponseWriter, r *http.Request) {
+	authors, err := api.db.Authors(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if authors == nil {
+		authors = []storage.Author{}
+	}
+	bytes, err := json.Marshal(authors)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	api.writeCached(w, r, bytes, "", 0)
+}
+
+// Получение автора по ID.
+func (api *API) authorHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	author, err := api.db.Author(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	bytes, err := json.Marshal(author)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	api.writeCached(w, r, bytes, "", 0)
+}
+
+// Создание автора. В ответе возвращается созданный автор с присвоенным ID.
+func (api *API) addAuthorHandler(w http.ResponseWriter, r *http.Request) {
+	var a storage.Author
+	err := json.NewDecoder(r.Body).Decode(&a)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, "некорректное тело запроса: "+err.Error())
+		return
+	}
+
+	validationErrors := api.validateAuthor(a)
+	if len(validationErrors) > 0 {
+		writeErrors(w, http.StatusBadRequest, validationErrors...)
+		return
+	}
+
+	a.ID, err = api.db.AddAuthor(r.Context(), a)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(a)
+}
+
+// Переименование автора.
+func (api *API) updateAuthorHandler(w http.ResponseWriter, r *http.Request) {
+	var a storage.Author
+	err := json.NewDecoder(r.Body).Decode(&a)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, "некорректное тело запроса: "+err.Error())
+		return
+	}
+	a.ID, _ = strconv.Atoi(mux.Vars(r)["id"])
+
+	validationErrors := api.validateAuthor(a)
+	if len(validationErrors) > 0 {
+		writeErrors(w, http.StatusBadRequest, validationErrors...)
+		return
+	}
+
+	err = api.db.UpdateAuthor(r.Context(), a)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Удаление автора. Параметр posts задаёт политику для публикаций автора:
+// restrict (по умолчанию) запрещает удаление автора с публикациями,
+// cascade удаляет автора вместе с его публикациями.
+func (api *API) deleteAuthorHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	var cascade bool
+	switch policy := r.URL.Query().Get("posts"); policy {
+	case "", "restrict":
+	case "cascade":
+		cascade = true
+	default:
+		writeErrors(w, http.StatusBadRequest, "posts должен быть restrict или cascade")
+		return
+	}
+
+	err := api.db.DeleteAuthor(r.Context(), id, cascade)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
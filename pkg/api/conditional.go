@@ -0,0 +1,124 @@
+package api
+
+import (
+	"GoNews/pkg/storage"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// etag возвращает ETag публикации: номер её версии в кавычках.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersion извлекает из заголовка If-Match версию публикации,
+// которую клиент собирается изменить. If-Match: * разрешает изменение
+// любой версии, ему соответствует 0.
+// Если заголовка нет или он некорректен, отправляет ответ 428 или 412
+// и возвращает ok == false.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (version int, ok bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		writeErrors(w, http.StatusPreconditionRequired, "требуется заголовок If-Match с ETag публикации")
+		return 0, false
+	}
+	if header == "*" {
+		return 0, true
+	}
+
+	// Слабые ETag не подходят для If-Match, а ETag другого вида не может
+	// совпасть ни с одной версией публикации.
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version <= 0 || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) {
+		writeErrors(w, http.StatusPreconditionFailed, "If-Match не совпадает с текущей версией публикации")
+		return 0, false
+	}
+
+	return version, true
+}
+
+// cacheControl возвращает значение заголовка Cache-Control для ответа на
+// запрос r. Ответы на запросы с авторизацией могут содержать черновики,
+// поэтому не сохраняются в общих кешах.
+func (api *API) cacheControl(r *http.Request) string {
+	if r.Header.Get("Authorization") != "" {
+		return "private, no-cache"
+	}
+	if maxAge := api.cfg.Cache.MaxAge; maxAge > 0 {
+		return fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+	}
+	return "no-cache"
+}
+
+// writeCached отправляет тело ответа на GET-запрос с заголовками кеширования.
+// Если tag пуст, ETag вычисляется по телу ответа и заголовкам постраничного
+// чтения. modified - момент последнего изменения данных ответа, 0 - неизвестен.
+// Если у клиента уже есть актуальная версия ответа, отправляется 304 без тела.
+func (api *API) writeCached(w http.ResponseWriter, r *http.Request, body []byte, tag string, modified int64) {
+	h := w.Header()
+	if tag == "" {
+		sum := sha256.New()
+		sum.Write(body)
+		for _, name := range []string{"X-Total-Count", "X-Next-Cursor", "Link"} {
+			fmt.Fprintf(sum, "\n%s: %s", name, h.Get(name))
+		}
+		tag = `"` + hex.EncodeToString(sum.Sum(nil)[:16]) + `"`
+	}
+
+	h.Set("Cache-Control", api.cacheControl(r))
+	h.Add("Vary", "Authorization")
+	h.Set("ETag", tag)
+	if modified > 0 {
+		h.Set("Last-Modified", time.Unix(modified, 0).UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, tag, modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Write(body)
+}
+
+// notModified проверяет условия If-None-Match и If-Modified-Since.
+// If-Modified-Since учитывается, только если нет If-None-Match.
+func notModified(r *http.Request, tag string, modified int64) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		return etagMatches(header, tag)
+	}
+	if header := r.Header.Get("If-Modified-Since"); header != "" && modified > 0 {
+		t, err := http.ParseTime(header)
+		return err == nil && modified <= t.Unix()
+	}
+	return false
+}
+
+// etagMatches сравнивает ETag со списком из заголовка If-None-Match.
+// Для GET-запросов сравнение слабое: префикс W/ не учитывается.
+func etagMatches(header, tag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// lastModified возвращает наибольший из моментов создания и изменения публикаций.
+func lastModified(posts ...storage.Post) int64 {
+	var modified int64
+	for _, p := range posts {
+		if p.CreatedAt > modified {
+			modified = p.CreatedAt
+		}
+		if p.UpdatedAt > modified {
+			modified = p.UpdatedAt
+		}
+	}
+	return modified
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"GoNews/pkg/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// writeErrors отправляет клиенту список ошибок в формате ErrorResponse.
+func writeErrors(w http.ResponseWriter, status int, errs ...string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Errors: errs})
+}
+
+// writeError отправляет клиенту ошибку хранилища с соответствующим ей
+// кодом ответа HTTP. Неизвестные ошибки считаются внутренними: их текст
+// может содержать запросы SQL и адреса баз данных, поэтому он пишется
+// только в журнал сервера, а клиент получает общее сообщение.
+func writeError(w http.ResponseWriter, err error) {
+	status := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("Внутренняя ошибка: %v", err)
+		writeErrors(w, status, "внутренняя ошибка сервера")
+		return
+	}
+	writeErrors(w, status, err.Error())
+}
+
+// errorStatus сопоставляет ошибке хранилища код ответа HTTP.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrStale):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, storage.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, storage.ErrAuthorNotFound), errors.Is(err, storage.ErrInvalid):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
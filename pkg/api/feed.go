@@ -0,0 +1,92 @@
+package api
+
+import (
+	"GoNews/pkg/feed"
+	"GoNews/pkg/storage"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Регистрация обработчиков лент для подписки.
+func (api *API) feedEndpoints() {
+	api.router.HandleFunc("/feed.rss", api.feedHandler("application/rss+xml", feed.RSS)).Methods(http.MethodGet, http.MethodOptions)
+	api.router.HandleFunc("/feed.atom", api.feedHandler("application/atom+xml", feed.Atom)).Methods(http.MethodGet, http.MethodOptions)
+	api.router.HandleFunc("/feed.json", api.feedHandler("application/feed+json", feed.JSON)).Methods(http.MethodGet, http.MethodOptions)
+}
+
+// baseURL возвращает публичный адрес сервера для абсолютных ссылок:
+// server.base_url из конфигурации или, если он не задан, адрес,
+// по которому клиент выполнил запрос.
+func (api *API) baseURL(r *http.Request) string {
+	if base := strings.TrimSuffix(api.cfg.Server.BaseURL, "/"); base != "" {
+		return base
+	}
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// feedHandler возвращает обработчик ленты последних опубликованных публикаций
+// в формате, который строит build. Параметр author_id ограничивает ленту
+// публикациями одного автора, limit и offset задают страницу ленты;
+// адрес следующей страницы передаётся в самой ленте.
+func (api *API) feedHandler(contentType string, build func(feed.Channel, []storage.Post) ([]byte, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, offset, validationErrors := parsePage(r.URL.Query())
+		if len(validationErrors) > 0 {
+			writeErrors(w, http.StatusBadRequest, validationErrors...)
+			return
+		}
+
+		filter := storage.PostFilter{
+			Status:   storage.StatusPublished,
+			SortBy:   storage.SortByPublishedAt,
+			SortDesc: true,
+		}
+		base := api.baseURL(r)
+		ch := feed.Channel{
+			Title:       "GoNews",
+			Description: "Последние публикации GoNews",
+			Link:        base,
+			Self:        base + r.URL.RequestURI(),
+		}
+
+		if v := r.URL.Query().Get("author_id"); v != "" {
+			id, err := strconv.Atoi(v)
+			if err != nil || id <= 0 {
+				writeErrors(w, http.StatusBadRequest, "author_id должен быть положительным числом")
+				return
+			}
+			author, err := api.db.Author(r.Context(), id)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			filter.AuthorID = id
+			ch.Title = "GoNews: " + author.Name
+			ch.Description = "Последние публикации автора " + author.Name
+		}
+
+		posts, total, err := api.db.Posts(r.Context(), filter, limit, offset)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		ch.Updated = lastModified(posts...)
+		if offset+limit < total {
+			ch.Next = base + pageURL(r, limit, offset+limit)
+		}
+
+		bytes, err := build(ch, posts)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+		setPageHeaders(w, r, limit, offset, total)
+		api.writeCached(w, r, bytes, "", ch.Updated)
+	}
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"GoNews/pkg/storage"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// parsePostFilter извлекает условия отбора и сортировки публикаций
+// из строки запроса: author_id, created_after, created_before,
+// status, title, sort и order.
+func parsePostFilter(q url.Values) (storage.PostFilter, []string) {
+	var f storage.PostFilter
+	var validationErrors []string
+
+	if v := q.Get("author_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			validationErrors = append(validationErrors, "author_id должен быть положительным числом")
+		} else {
+			f.AuthorID = id
+		}
+	}
+
+	if v := q.Get("created_after"); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			validationErrors = append(validationErrors, "created_after: "+err.Error())
+		} else {
+			f.CreatedAfter = t
+		}
+	}
+
+	if v := q.Get("created_before"); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			validationErrors = append(validationErrors, "created_before: "+err.Error())
+		} else {
+			f.CreatedBefore = t
+		}
+	}
+
+	if f.CreatedAfter != 0 && f.CreatedBefore != 0 && f.CreatedAfter >= f.CreatedBefore {
+		validationErrors = append(validationErrors, "created_after должен быть раньше created_before")
+	}
+
+	switch status := q.Get("status"); status {
+	case "", storage.StatusDraft, storage.StatusScheduled, storage.StatusPublished:
+		f.Status = status
+	default:
+		validationErrors = append(validationErrors, fmt.Sprintf("состояние публикации %q не поддерживается", status))
+	}
+
+	f.TitleContains = q.Get("title")
+
+	switch sortBy := q.Get("sort"); sortBy {
+	case "", storage.SortByID, storage.SortByCreatedAt, storage.SortByPublishedAt, storage.SortByTitle:
+		f.SortBy = sortBy
+	default:
+		validationErrors = append(validationErrors, fmt.Sprintf("сортировка по полю %q не поддерживается", sortBy))
+	}
+
+	switch order := q.Get("order"); order {
+	case "", "asc":
+	case "desc":
+		f.SortDesc = true
+	default:
+		validationErrors = append(validationErrors, "order должен быть asc или desc")
+	}
+
+	return f, validationErrors
+}
+
+// parseTime разбирает момент времени, заданный как Unix-время в секундах,
+// в формате RFC 3339 или как дата ГГГГ-ММ-ДД.
+func parseTime(v string) (int64, error) {
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return n, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.Unix(), nil
+	}
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t.Unix(), nil
+	}
+	return 0, fmt.Errorf("некорректное время %q", v)
+}
@@ -0,0 +1,324 @@
+package api
+
+import (
+	"GoNews/config"
+	"GoNews/pkg/auth"
+	"GoNews/pkg/oidc"
+	"GoNews/pkg/storage"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Регистрация обработчиков входа через OpenID Connect.
+// Вход доступен, если задан auth.oidc.issuer.
+func (api *API) oidcEndpoints() {
+	api.router.HandleFunc("/auth/oidc/login", api.oidcLoginHandler).Methods(http.MethodGet, http.MethodOptions)
+	api.router.HandleFunc("/auth/oidc/callback", api.oidcCallbackHandler).Methods(http.MethodGet, http.MethodOptions)
+}
+
+// newOIDC создаёт клиента провайдера OpenID Connect по параметрам auth.oidc.
+// Если провайдер не задан, возвращает nil.
+func newOIDC(cfg config.Config) (*oidc.Client, error) {
+	c := cfg.Auth.OIDC
+	if c.Issuer == "" {
+		return nil, nil
+	}
+	if c.ClientID == "" {
+		return nil, fmt.Errorf("для входа через OIDC требуется auth.oidc.client_id")
+	}
+	if cfg.Auth.JWT.Secret == "" {
+		return nil, fmt.Errorf("для входа через OIDC требуется auth.jwt.hs256_secret")
+	}
+	for group, role := range c.Roles {
+		if !auth.ValidRole(role) {
+			return nil, fmt.Errorf("auth.oidc.roles: неизвестная роль %q для группы %q", role, group)
+		}
+	}
+	if c.DefaultRole != "" && !auth.ValidRole(c.DefaultRole) {
+		return nil, fmt.Errorf("auth.oidc.default_role: неизвестная роль %q", c.DefaultRole)
+	}
+
+	return oidc.New(oidc.Config{
+		Issuer:       c.Issuer,
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		Scopes:       c.Scopes,
+		Leeway:       cfg.Auth.JWT.Leeway,
+	}, &http.Client{Timeout: 10 * time.Second}), nil
+}
+
+// oidcFlowCookie - имя cookie с состоянием незавершённого входа.
+const oidcFlowCookie = "gonews_oidc"
+
+// oidcFlowTTL - время, за которое пользователь должен завершить вход у провайдера.
+const oidcFlowTTL = 10 * time.Minute
+
+// oidcFlow - состояние входа между перенаправлением к провайдеру и возвратом.
+// Хранится в cookie браузера, подписанной секретом HS256, поэтому
+// вход не зависит от того, какой экземпляр сервера обработает возврат.
+type oidcFlow struct {
+	State     string
+	Nonce     string
+	Verifier  string // code_verifier PKCE
+	ExpiresAt int64
+}
+
+// signFlow упаковывает состояние входа в значение cookie.
+func (api *API) signFlow(f oidcFlow) (string, error) {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + api.flowMAC(payload), nil
+}
+
+// parseFlow проверяет подпись и срок действия cookie и возвращает состояние входа.
+func (api *API) parseFlow(value string, now time.Time) (oidcFlow, error) {
+	var f oidcFlow
+	i := strings.LastIndexByte(value, '.')
+	if i < 0 || !hmac.Equal([]byte(value[i+1:]), []byte(api.flowMAC(value[:i]))) {
+		return f, fmt.Errorf("%w: неверная подпись состояния входа", auth.ErrUnauthenticated)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value[:i])
+	if err != nil {
+		return f, fmt.Errorf("%w: некорректное состояние входа", auth.ErrUnauthenticated)
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return f, fmt.Errorf("%w: некорректное состояние входа", auth.ErrUnauthenticated)
+	}
+	if now.Unix() >= f.ExpiresAt {
+		return f, fmt.Errorf("%w: время входа истекло, начните вход заново", auth.ErrUnauthenticated)
+	}
+	return f, nil
+}
+
+// flowMAC возвращает подпись HMAC-SHA256 значения cookie.
+func (api *API) flowMAC(payload string) string {
+	mac := hmac.New(sha256.New, []byte(api.cfg.Auth.JWT.Secret))
+	mac.Write([]byte("oidc-flow:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// oidcRedirectURL возвращает адрес, на который провайдер вернёт пользователя.
+func (api *API) oidcRedirectURL(r *http.Request) string {
+	if u := api.cfg.Auth.OIDC.RedirectURL; u != "" {
+		return u
+	}
+	return api.baseURL(r) + "/auth/oidc/callback"
+}
+
+// setFlowCookie записывает cookie состояния входа; пустое value удаляет её.
+func (api *API) setFlowCookie(w http.ResponseWriter, r *http.Request, value string) {
+	c := &http.Cookie{
+		Name:     oidcFlowCookie,
+		Value:    value,
+		Path:     "/auth/oidc",
+		HttpOnly: true,
+		Secure:   r.TLS != nil || strings.HasPrefix(api.baseURL(r), "https://"),
+		// Возврат от провайдера - переход с другого сайта, Lax его пропускает.
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(oidcFlowTTL.Seconds()),
+	}
+	if value == "" {
+		c.MaxAge = -1
+	}
+	http.SetCookie(w, c)
+}
+
+// Начало входа через OpenID Connect: перенаправление на страницу входа
+// провайдера с параметрами state, nonce и PKCE.
+func (api *API) oidcLoginHandler(w http.ResponseWriter, r *http.Request) {
+	if api.oidc == nil {
+		writeErrors(w, http.StatusNotFound, "вход через OIDC не настроен")
+		return
+	}
+
+	var f oidcFlow
+	for _, s := range []*string{&f.State, &f.Nonce, &f.Verifier} {
+		var err error
+		if *s, err = oidc.RandomString(); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	f.ExpiresAt = time.Now().Add(oidcFlowTTL).Unix()
+
+	u, err := api.oidc.AuthCodeURL(r.Context(), api.oidcRedirectURL(r), f.State, f.Nonce, oidc.Challenge(f.Verifier))
+	if err != nil {
+		writeErrors(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	value, err := api.signFlow(f)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	api.setFlowCookie(w, r, value)
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, u, http.StatusFound)
+}
+
+// Завершение входа через OpenID Connect. Код авторизации обменивается
+// на ID-токен, пользователь находится по учётной записи провайдера
+// или создаётся вместе с автором, см. oidcUser, роль определяется группами
+// пользователя у провайдера, см. oidcRole. В ответе возвращается JWT,
+// как при входе по паролю.
+func (api *API) oidcCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	if api.oidc == nil {
+		writeErrors(w, http.StatusNotFound, "вход через OIDC не настроен")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	q := r.URL.Query()
+
+	cookie, err := r.Cookie(oidcFlowCookie)
+	if err != nil {
+		unauthorized(w, false, "нет состояния входа, начните вход с /auth/oidc/login")
+		return
+	}
+	api.setFlowCookie(w, r, "")
+	f, err := api.parseFlow(cookie.Value, time.Now())
+	if err != nil {
+		unauthorized(w, false, err.Error())
+		return
+	}
+	if !hmac.Equal([]byte(q.Get("state")), []byte(f.State)) {
+		unauthorized(w, false, "параметр state не совпадает с начатым входом")
+		return
+	}
+	if e := q.Get("error"); e != "" {
+		unauthorized(w, false, strings.TrimSpace("провайдер OIDC отказал во входе: "+e+" "+q.Get("error_description")))
+		return
+	}
+
+	claims, err := api.oidc.Exchange(r.Context(), api.oidcRedirectURL(r), q.Get("code"), f.Verifier, f.Nonce)
+	if errors.Is(err, auth.ErrUnauthenticated) {
+		unauthorized(w, false, err.Error())
+		return
+	}
+	if err != nil {
+		writeErrors(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	c := api.cfg.Auth.OIDC
+	role := oidcRole(claims.Strings(c.RoleClaim), c.Roles, c.DefaultRole)
+	u, err := api.oidcUser(r, claims, role)
+	if errors.Is(err, errOIDCEmailNotVerified) {
+		forbidden(w, "провайдер OIDC не подтвердил адрес электронной почты пользователя")
+		return
+	}
+	if errors.Is(err, errOIDCAccountExists) {
+		forbidden(w, "адрес "+claims.Email+" занят учётной записью с паролем, войдите по паролю")
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if u.Role == "" {
+		forbidden(w, "у пользователя нет групп, которым разрешён вход")
+		return
+	}
+
+	token, expiresAt, err := api.jwt.Issue(userPrincipal(u), time.Now(), api.cfg.Auth.JWT.TokenTTL)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(struct {
+		Token     string
+		ExpiresAt int64
+	}{token, expiresAt.Unix()})
+}
+
+// errOIDCAccountExists - адрес из ID-токена занят учётной записью,
+// к которой нельзя автоматически привязать вход через OIDC.
+var errOIDCAccountExists = errors.New("адрес электронной почты занят другой учётной записью")
+
+// errOIDCEmailNotVerified - провайдер не подтвердил адрес из ID-токена,
+// поэтому по нему нельзя ни найти, ни создать пользователя.
+var errOIDCEmailNotVerified = errors.New("адрес электронной почты не подтверждён провайдером")
+
+// oidcUser возвращает пользователя, привязанного к учётной записи провайдера
+// (поля iss и sub ID-токена). Адрес электронной почты у провайдера может
+// смениться или перейти к другому человеку, поэтому по адресу находится
+// только учётная запись без пароля, созданная входом через OIDC до того,
+// как сервер начал хранить iss и sub: она привязывается к провайдеру.
+// Учётную запись с паролем или привязанную к другому провайдеру владелец
+// адреса у провайдера не получает, возвращается errOIDCAccountExists.
+// Если пользователя нет, он создаётся без пароля вместе с автором: войти
+// по паролю он сможет только после сброса пароля.
+//
+// Роль, вычисленная по группам пользователя у провайдера, сохраняется
+// в учётной записи при каждом входе, в том числе пустая: иначе пользователь,
+// исключённый из групп у провайдера, сохранил бы прежнюю роль для входа
+// по паролю. Пользователь без роли не создаётся и не привязывается,
+// возвращается пустой storage.User.
+func (api *API) oidcUser(r *http.Request, claims oidc.Claims, role string) (storage.User, error) {
+	u, err := api.db.UserByOIDC(r.Context(), claims.Issuer, claims.Subject)
+	if err == nil {
+		return api.setUserRole(r.Context(), u, role)
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return storage.User{}, err
+	}
+	if role == "" {
+		return storage.User{}, nil
+	}
+	if claims.Email == "" || !claims.EmailVerified {
+		return storage.User{}, errOIDCEmailNotVerified
+	}
+
+	email := normalizeEmail(claims.Email)
+	u, err = api.db.UserByEmail(r.Context(), email)
+	if err == nil {
+		if u.PasswordHash != "" || u.OIDCSubject != "" {
+			return storage.User{}, errOIDCAccountExists
+		}
+		if err := api.db.LinkOIDC(r.Context(), u.ID, claims.Issuer, claims.Subject); err != nil {
+			return storage.User{}, err
+		}
+		return api.setUserRole(r.Context(), u, role)
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return storage.User{}, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.PreferredUsername
+	}
+	if name == "" {
+		name = email
+	}
+	return api.db.AddUser(r.Context(), storage.User{
+		Email:       email,
+		Name:        name,
+		Role:        role,
+		CreatedAt:   time.Now().Unix(),
+		OIDCIssuer:  claims.Issuer,
+		OIDCSubject: claims.Subject,
+	})
+}
+
+// setUserRole сохраняет роль пользователя u и возвращает его текущее
+// состояние. Смена роли отзывает выданные пользователю токены.
+func (api *API) setUserRole(ctx context.Context, u storage.User, role string) (storage.User, error) {
+	if u.Role == role {
+		return u, nil
+	}
+	if err := api.db.SetUserRole(ctx, u.ID, role); err != nil {
+		return storage.User{}, fmt.Errorf("не удалось сохранить роль пользователя: %w", err)
+	}
+	return api.db.User(ctx, u.ID)
+}
@@ -0,0 +1,437 @@
+package api
+
+import (
+	"GoNews/config"
+	"GoNews/pkg/auth"
+	"GoNews/pkg/storage"
+	"GoNews/pkg/storage/memdb"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testClientID - идентификатор клиента GoNews у тестового провайдера.
+const testClientID = "gonews"
+
+// testProvider - тестовый провайдер OpenID Connect: документ обнаружения,
+// набор ключей JWKS, страница входа и обмен кода на ID-токен с проверкой PKCE.
+type testProvider struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	// Параметры последнего запроса к странице входа.
+	challenge string
+	nonce     string
+	// claims изменяет поля ID-токена перед подписью.
+	claims func(c map[string]interface{})
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &testProvider{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("client_id") != testClientID || q.Get("code_challenge_method") != "S256" {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		p.challenge, p.nonce = q.Get("code_challenge"), q.Get("nonce")
+		back := url.Values{"code": {"code-1"}, "state": {q.Get("state")}}
+		http.Redirect(w, r, q.Get("redirect_uri")+"?"+back.Encode(), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if r.PostFormValue("code") != "code-1" || base64.RawURLEncoding.EncodeToString(sum[:]) != p.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": p.idToken(t)})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// idToken выпускает ID-токен RS256 для пользователя alice.
+func (p *testProvider) idToken(t *testing.T) string {
+	now := time.Now()
+	c := map[string]interface{}{
+		"iss":            p.URL,
+		"aud":            testClientID,
+		"sub":            "alice-id",
+		"email":          "Alice@example.com",
+		"email_verified": true,
+		"name":           "Alice",
+		"nonce":          p.nonce,
+		"groups":         []string{"writers"},
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	}
+	if p.claims != nil {
+		p.claims(c)
+	}
+
+	h, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": "k1"})
+	payload, _ := json.Marshal(c)
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// newOIDCTestAPI создаёт API на memdb, настроенный на вход через провайдера p.
+func newOIDCTestAPI(t *testing.T, p *testProvider, db storage.Interface) *API {
+	var cfg config.Config
+	cfg.Server.BaseURL = "http://gonews.test"
+	cfg.Auth.JWT.Secret = "0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0"
+	cfg.Auth.JWT.TokenTTL = time.Hour
+	cfg.Auth.Users.ResetTTL = time.Hour
+	cfg.Auth.OIDC.Issuer = p.URL
+	cfg.Auth.OIDC.ClientID = testClientID
+	cfg.Auth.OIDC.RoleClaim = "groups"
+	cfg.Auth.OIDC.Roles = map[string]string{"writers": auth.RoleAuthor, "editors": auth.RoleEditor}
+
+	api, err := New(db, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return api
+}
+
+// oidcLogin проходит вход через провайдера: /auth/oidc/login, страница
+// входа провайдера и возврат на /auth/oidc/callback. tamper позволяет
+// изменить адрес возврата перед запросом к серверу.
+func oidcLogin(t *testing.T, api *API, tamper func(callback *url.URL)) *httptest.ResponseRecorder {
+	login := httptest.NewRecorder()
+	api.Router().ServeHTTP(login, httptest.NewRequest(http.MethodGet, "http://gonews.test/auth/oidc/login", nil))
+	if login.Code != http.StatusFound {
+		t.Fatalf("/auth/oidc/login: код %d, ожидается 302: %s", login.Code, login.Body)
+	}
+	authorize, err := url.Parse(login.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authorize.Query().Get("code_challenge") == "" {
+		t.Fatal("в запросе авторизации нет code_challenge")
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authorize.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tamper != nil {
+		tamper(callback)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, callback.String(), nil)
+	for _, c := range login.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	rec := httptest.NewRecorder()
+	api.Router().ServeHTTP(rec, req)
+	return rec
+}
+
+// tokenPrincipal проверяет JWT из ответа на вход и возвращает клиента, которому он выдан.
+func tokenPrincipal(t *testing.T, api *API, rec *httptest.ResponseRecorder) auth.Principal {
+	var resp struct {
+		Token     string
+		ExpiresAt int64
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("ответ на вход: %v", err)
+	}
+	p, err := api.jwtPrincipal(context.Background(), resp.Token)
+	if err != nil {
+		t.Fatalf("выданный токен не проходит проверку: %v", err)
+	}
+	return p
+}
+
+func TestOIDCCallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		claims   func(c map[string]interface{})
+		tamper   func(p *testProvider, callback *url.URL)
+		status   int
+		wantRole string
+	}{
+		{name: "ok", status: http.StatusOK, wantRole: auth.RoleAuthor},
+		{
+			name:     "group mapped to role",
+			claims:   func(c map[string]interface{}) { c["groups"] = []string{"Editors"} },
+			status:   http.StatusOK,
+			wantRole: auth.RoleEditor,
+		},
+		{
+			name:   "no mapped groups",
+			claims: func(c map[string]interface{}) { c["groups"] = []string{"guests"} },
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "email not verified",
+			claims: func(c map[string]interface{}) { c["email_verified"] = false },
+			status: http.StatusForbidden,
+		},
+		{
+			name: "bad state",
+			tamper: func(p *testProvider, callback *url.URL) {
+				q := callback.Query()
+				q.Set("state", "forged")
+				callback.RawQuery = q.Encode()
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "bad PKCE verifier",
+			tamper: func(p *testProvider, callback *url.URL) { p.challenge = "forged" },
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "bad nonce",
+			claims: func(c map[string]interface{}) { c["nonce"] = "forged" },
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "wrong audience",
+			claims: func(c map[string]interface{}) { c["aud"] = "other-client" },
+			status: http.StatusUnauthorized,
+		},
+		{
+			name: "wrong authorized party",
+			claims: func(c map[string]interface{}) {
+				c["aud"] = []string{testClientID, "other-client"}
+				c["azp"] = "other-client"
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "expired token",
+			claims: func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "wrong issuer",
+			claims: func(c map[string]interface{}) { c["iss"] = "https://evil.example" },
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProvider(t)
+			p.claims = tt.claims
+			api := newOIDCTestAPI(t, p, memdb.New())
+
+			var tamper func(*url.URL)
+			if tt.tamper != nil {
+				tamper = func(callback *url.URL) { tt.tamper(p, callback) }
+			}
+			rec := oidcLogin(t, api, tamper)
+			if rec.Code != tt.status {
+				t.Fatalf("код %d, ожидается %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			principal := tokenPrincipal(t, api, rec)
+			if principal.Role != tt.wantRole {
+				t.Errorf("роль %q, ожидается %q", principal.Role, tt.wantRole)
+			}
+			if principal.Name != "Alice" || principal.AuthorID == 0 {
+				t.Errorf("клиент %+v, ожидается пользователь Alice с автором", principal)
+			}
+		})
+	}
+}
+
+func TestOIDCUserLinking(t *testing.T) {
+	t.Run("same subject with new email", func(t *testing.T) {
+		p := newTestProvider(t)
+		api := newOIDCTestAPI(t, p, memdb.New())
+
+		first := oidcLogin(t, api, nil)
+		if first.Code != http.StatusOK {
+			t.Fatalf("первый вход: код %d: %s", first.Code, first.Body)
+		}
+		p.claims = func(c map[string]interface{}) { c["email"] = "alice@new.example" }
+		second := oidcLogin(t, api, nil)
+		if second.Code != http.StatusOK {
+			t.Fatalf("повторный вход: код %d: %s", second.Code, second.Body)
+		}
+		if a, b := tokenPrincipal(t, api, first).Subject, tokenPrincipal(t, api, second).Subject; a != b {
+			t.Errorf("повторный вход выполнен как %s, ожидается %s", b, a)
+		}
+	})
+
+	t.Run("email of password account", func(t *testing.T) {
+		p := newTestProvider(t)
+		db := memdb.New()
+		api := newOIDCTestAPI(t, p, db)
+		_, err := db.AddUser(context.Background(), storage.User{
+			Email:        "alice@example.com",
+			Name:         "Alice",
+			PasswordHash: "$2a$10$hash",
+			Role:         auth.RoleAdmin,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rec := oidcLogin(t, api, nil)
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("код %d, ожидается 403: %s", rec.Code, rec.Body)
+		}
+	})
+
+	t.Run("email of account linked to other subject", func(t *testing.T) {
+		p := newTestProvider(t)
+		db := memdb.New()
+		api := newOIDCTestAPI(t, p, db)
+		_, err := db.AddUser(context.Background(), storage.User{
+			Email:       "alice@example.com",
+			Name:        "Alice",
+			Role:        auth.RoleAuthor,
+			OIDCIssuer:  p.URL,
+			OIDCSubject: "someone-else",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rec := oidcLogin(t, api, nil)
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("код %d, ожидается 403: %s", rec.Code, rec.Body)
+		}
+	})
+
+	t.Run("legacy account without password", func(t *testing.T) {
+		p := newTestProvider(t)
+		db := memdb.New()
+		api := newOIDCTestAPI(t, p, db)
+		u, err := db.AddUser(context.Background(), storage.User{Email: "alice@example.com", Name: "Alice", Role: auth.RoleAuthor})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rec := oidcLogin(t, api, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("код %d, ожидается 200: %s", rec.Code, rec.Body)
+		}
+		if got, want := tokenPrincipal(t, api, rec).Subject, userPrincipal(u).Subject; got != want {
+			t.Errorf("вход выполнен как %s, ожидается %s", got, want)
+		}
+		linked, err := db.UserByOIDC(context.Background(), p.URL, "alice-id")
+		if err != nil || linked.ID != u.ID {
+			t.Errorf("учётная запись провайдера не привязана к пользователю %d: %v", u.ID, err)
+		}
+	})
+}
+
+// passwordLogin задаёт пользователю email пароль через сброс пароля
+// и возвращает ответ на вход с этим паролем.
+func passwordLogin(t *testing.T, api *API, email string) *httptest.ResponseRecorder {
+	var resetToken string
+	api.OnPasswordReset(func(u storage.User, token string) { resetToken = token })
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		api.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "http://gonews.test"+path, strings.NewReader(body)))
+		return rec
+	}
+	if rec := post("/auth/password/reset", `{"Email": "`+email+`"}`); rec.Code != http.StatusAccepted || resetToken == "" {
+		t.Fatalf("запрос сброса пароля: код %d: %s", rec.Code, rec.Body)
+	}
+	if rec := post("/auth/password/reset/confirm", `{"Token": "`+resetToken+`", "Password": "password1"}`); rec.Code != http.StatusOK {
+		t.Fatalf("сброс пароля: код %d: %s", rec.Code, rec.Body)
+	}
+	return post("/auth/login", `{"Email": "`+email+`", "Password": "password1"}`)
+}
+
+func TestOIDCRoleDowngrade(t *testing.T) {
+	tests := []struct {
+		name     string
+		groups   []string
+		status   int    // код ответа на вход через OIDC после понижения
+		wantRole string // роль в токене входа по паролю
+	}{
+		{"moved to writers", []string{"writers"}, http.StatusOK, auth.RoleAuthor},
+		{"removed from all groups", []string{"guests"}, http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProvider(t)
+			api := newOIDCTestAPI(t, p, memdb.New())
+
+			p.claims = func(c map[string]interface{}) { c["groups"] = []string{"editors"} }
+			first := oidcLogin(t, api, nil)
+			if first.Code != http.StatusOK {
+				t.Fatalf("первый вход: код %d: %s", first.Code, first.Body)
+			}
+			var editorToken struct{ Token string }
+			json.NewDecoder(first.Body).Decode(&editorToken)
+
+			p.claims = func(c map[string]interface{}) { c["groups"] = tt.groups }
+			if rec := oidcLogin(t, api, nil); rec.Code != tt.status {
+				t.Fatalf("вход после понижения: код %d, ожидается %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if _, err := api.jwtPrincipal(context.Background(), editorToken.Token); err == nil {
+				t.Error("токен с прежней ролью действует после её смены")
+			}
+
+			rec := passwordLogin(t, api, "alice@example.com")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("вход по паролю: код %d: %s", rec.Code, rec.Body)
+			}
+			if got := tokenPrincipal(t, api, rec).Role; got != tt.wantRole {
+				t.Errorf("вход по паролю с ролью %q, ожидается %q", got, tt.wantRole)
+			}
+		})
+	}
+}
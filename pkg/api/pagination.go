@@ -0,0 +1,139 @@
+package api
+
+import (
+	"GoNews/pkg/storage"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPageLimit = 20  // размер страницы по умолчанию
+	maxPageLimit     = 100 // максимальный размер страницы
+)
+
+// parsePage извлекает параметры limit и offset из строки запроса.
+func parsePage(q url.Values) (limit, offset int, validationErrors []string) {
+	limit = defaultPageLimit
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxPageLimit {
+			validationErrors = append(validationErrors, fmt.Sprintf("limit должен быть числом от 1 до %d", maxPageLimit))
+		} else {
+			limit = n
+		}
+	}
+
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			validationErrors = append(validationErrors, "offset должен быть неотрицательным числом")
+		} else {
+			offset = n
+		}
+	}
+
+	return limit, offset, validationErrors
+}
+
+// setPageHeaders записывает в ответ общее количество записей
+// и ссылки на соседние страницы в заголовке Link.
+func setPageHeaders(w http.ResponseWriter, r *http.Request, limit, offset, total int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+
+	var links []string
+	if offset+limit < total {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(r, limit, offset+limit)))
+	}
+	if offset > 0 {
+		prev := offset - limit
+		if prev < 0 {
+			prev = 0
+		}
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageURL(r, limit, prev)))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
+// pageURL возвращает адрес текущего запроса с новыми параметрами страницы.
+func pageURL(r *http.Request, limit, offset int) string {
+	u := *r.URL
+	q := u.Query()
+	q.Set("limit", strconv.Itoa(limit))
+	q.Set("offset", strconv.Itoa(offset))
+	u.RawQuery = q.Encode()
+	return u.RequestURI()
+}
+
+// encodeCursor упаковывает позицию в ленте в непрозрачный токен.
+func encodeCursor(c storage.Cursor) string {
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt, c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor восстанавливает позицию в ленте из токена.
+func decodeCursor(token string) (storage.Cursor, error) {
+	var c storage.Cursor
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, errors.New("некорректный курсор")
+	}
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return c, errors.New("некорректный курсор")
+	}
+	c.CreatedAt, err = strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return c, errors.New("некорректный курсор")
+	}
+	c.ID, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return c, errors.New("некорректный курсор")
+	}
+
+	return c, nil
+}
+
+// parseCursorPage извлекает параметры cursor и limit для keyset-пагинации.
+// Пустое значение cursor означает первую страницу ленты.
+func parseCursorPage(q url.Values) (cursor *storage.Cursor, limit int, validationErrors []string) {
+	limit, _, validationErrors = parsePage(q)
+
+	if q.Get("offset") != "" {
+		validationErrors = append(validationErrors, "параметры cursor и offset нельзя использовать одновременно")
+	}
+	if q.Get("sort") != "" || q.Get("order") != "" {
+		validationErrors = append(validationErrors, "при использовании cursor сортировка задаётся по дате создания")
+	}
+
+	if token := q.Get("cursor"); token != "" {
+		c, err := decodeCursor(token)
+		if err != nil {
+			validationErrors = append(validationErrors, err.Error())
+		} else {
+			cursor = &c
+		}
+	}
+
+	return cursor, limit, validationErrors
+}
+
+// setCursorHeaders записывает в ответ токен следующей страницы
+// и ссылку на неё в заголовке Link.
+func setCursorHeaders(w http.ResponseWriter, r *http.Request, next string) {
+	w.Header().Set("X-Next-Cursor", next)
+
+	u := *r.URL
+	q := u.Query()
+	q.Set("cursor", next)
+	u.RawQuery = q.Encode()
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, u.RequestURI()))
+}
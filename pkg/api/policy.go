@@ -0,0 +1,89 @@
+package api
+
+import (
+	"GoNews/pkg/auth"
+	"GoNews/pkg/storage"
+	"strings"
+)
+
+// Правила доступа к публикациям. Функции не зависят от HTTP:
+// они получают клиента и публикации и только принимают решение.
+//
+// Автор изменяет лишь свои публикации и не может передать публикацию
+// другому автору. Редактор и администратор изменяют любые публикации.
+
+// canAssignAuthor сообщает, может ли клиент p указать автором публикации authorID.
+func canAssignAuthor(p auth.Principal, authorID int) bool {
+	if p.HasRole(auth.RoleEditor) {
+		return true
+	}
+	return p.HasRole(auth.RoleAuthor) && p.AuthorID != 0 && p.AuthorID == authorID
+}
+
+// canCreatePost сообщает, может ли клиент p создать публикацию post.
+func canCreatePost(p auth.Principal, post storage.Post) bool {
+	return canAssignAuthor(p, post.AuthorID)
+}
+
+// canEditPost сообщает, может ли клиент p изменить или удалить
+// существующую публикацию current.
+func canEditPost(p auth.Principal, current storage.Post) bool {
+	return canAssignAuthor(p, current.AuthorID)
+}
+
+// canUpdatePost сообщает, может ли клиент p применить к публикации current
+// изменение update. Нулевой update.AuthorID оставляет автора прежним.
+func canUpdatePost(p auth.Principal, current, update storage.Post) bool {
+	if !canEditPost(p, current) {
+		return false
+	}
+	return update.AuthorID == 0 || canAssignAuthor(p, update.AuthorID)
+}
+
+// canPublishPost сообщает, может ли клиент p публиковать
+// и планировать публикации.
+func canPublishPost(p auth.Principal) bool {
+	return p.HasRole(auth.RoleEditor)
+}
+
+// canRestorePost сообщает, может ли клиент p восстанавливать
+// публикации из корзины.
+func canRestorePost(p auth.Principal) bool {
+	return p.HasRole(auth.RoleEditor)
+}
+
+// canManageAuthors сообщает, может ли клиент p создавать
+// и переименовывать авторов.
+func canManageAuthors(p auth.Principal) bool {
+	return p.HasRole(auth.RoleEditor)
+}
+
+// canDeleteAuthor сообщает, может ли клиент p удалять авторов.
+// Удаление автора может удалить и все его публикации вместе с историей,
+// поэтому оно доступно только администратору.
+func canDeleteAuthor(p auth.Principal) bool {
+	return p.HasRole(auth.RoleAdmin)
+}
+
+// canManageAPIKeys сообщает, может ли клиент p выпускать и отзывать ключи API.
+func canManageAPIKeys(p auth.Principal) bool {
+	return p.HasRole(auth.RoleAdmin)
+}
+
+// oidcRole возвращает роль пользователя, вошедшего через OpenID Connect:
+// наибольшую из ролей, сопоставленных его группам в roles (без учёта
+// регистра), или defaultRole, если ни одна группа не сопоставлена.
+// Пустая строка означает, что вход запрещён.
+func oidcRole(groups []string, roles map[string]string, defaultRole string) string {
+	var best auth.Principal
+	for _, g := range groups {
+		role, ok := roles[strings.ToLower(g)]
+		if ok && (best.Role == "" || !best.HasRole(role)) {
+			best.Role = role
+		}
+	}
+	if best.Role == "" {
+		return defaultRole
+	}
+	return best.Role
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"GoNews/pkg/auth"
+	"GoNews/pkg/storage"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	var (
+		anonymous = auth.Principal{}
+		noRole    = auth.Principal{Subject: "u", Scopes: []string{auth.ScopeWrite}, AuthorID: 1}
+		unbound   = auth.Principal{Subject: "u", Role: auth.RoleAuthor}
+		author    = auth.Principal{Subject: "u", Role: auth.RoleAuthor, AuthorID: 1}
+		editor    = auth.Principal{Subject: "u", Role: auth.RoleEditor}
+		admin     = auth.Principal{Subject: "u", Role: auth.RoleAdmin}
+		unknown   = auth.Principal{Subject: "u", Role: "root", AuthorID: 1}
+	)
+	own := storage.Post{ID: 1, AuthorID: 1}
+	other := storage.Post{ID: 2, AuthorID: 2}
+
+	// Действия над публикациями и авторами, которые проверяет политика.
+	actions := []struct {
+		name    string
+		allowed func(auth.Principal) bool
+	}{
+		{"create own", func(p auth.Principal) bool { return canCreatePost(p, own) }},
+		{"create other", func(p auth.Principal) bool { return canCreatePost(p, other) }},
+		{"edit own", func(p auth.Principal) bool { return canEditPost(p, own) }},
+		{"edit other", func(p auth.Principal) bool { return canEditPost(p, other) }},
+		{"update own", func(p auth.Principal) bool { return canUpdatePost(p, own, storage.Post{Title: "t"}) }},
+		{"update other", func(p auth.Principal) bool { return canUpdatePost(p, other, storage.Post{Title: "t"}) }},
+		{"reassign own", func(p auth.Principal) bool { return canUpdatePost(p, own, storage.Post{AuthorID: 2}) }},
+		{"claim other", func(p auth.Principal) bool { return canUpdatePost(p, other, storage.Post{AuthorID: 1}) }},
+		{"publish", canPublishPost},
+		{"restore", canRestorePost},
+		{"manage authors", canManageAuthors},
+		{"delete author", canDeleteAuthor},
+		{"manage api keys", canManageAPIKeys},
+	}
+
+	tests := []struct {
+		name      string
+		principal auth.Principal
+		allowed   []bool // в порядке actions
+	}{
+		{"anonymous", anonymous, []bool{false, false, false, false, false, false, false, false, false, false, false, false, false}},
+		{"no role", noRole, []bool{false, false, false, false, false, false, false, false, false, false, false, false, false}},
+		{"unknown role", unknown, []bool{false, false, false, false, false, false, false, false, false, false, false, false, false}},
+		{"author without author", unbound, []bool{false, false, false, false, false, false, false, false, false, false, false, false, false}},
+		{"author", author, []bool{true, false, true, false, true, false, false, false, false, false, false, false, false}},
+		{"editor", editor, []bool{true, true, true, true, true, true, true, true, true, true, true, false, false}},
+		{"admin", admin, []bool{true, true, true, true, true, true, true, true, true, true, true, true, true}},
+	}
+
+	for _, tt := range tests {
+		if len(tt.allowed) != len(actions) {
+			t.Fatalf("%s: ожидается %d решений, задано %d", tt.name, len(actions), len(tt.allowed))
+		}
+		for i, a := range actions {
+			if got := a.allowed(tt.principal); got != tt.allowed[i] {
+				t.Errorf("%s: %s = %v, ожидается %v", tt.name, a.name, got, tt.allowed[i])
+			}
+		}
+	}
+}
+
+func TestOIDCRole(t *testing.T) {
+	roles := map[string]string{"writers": auth.RoleAuthor, "editors": auth.RoleEditor, "admins": auth.RoleAdmin}
+
+	tests := []struct {
+		name        string
+		groups      []string
+		defaultRole string
+		want        string
+	}{
+		{"no groups", nil, "", ""},
+		{"no groups with default", nil, auth.RoleAuthor, auth.RoleAuthor},
+		{"unknown group", []string{"guests"}, "", ""},
+		{"mapped group", []string{"editors"}, "", auth.RoleEditor},
+		{"case insensitive", []string{"Editors"}, "", auth.RoleEditor},
+		{"highest role wins", []string{"admins", "writers", "editors"}, "", auth.RoleAdmin},
+		{"default does not override mapped", []string{"writers"}, auth.RoleEditor, auth.RoleAuthor},
+	}
+
+	for _, tt := range tests {
+		if got := oidcRole(tt.groups, roles, tt.defaultRole); got != tt.want {
+			t.Errorf("%s: oidcRole(%v) = %q, ожидается %q", tt.name, tt.groups, got, tt.want)
+		}
+	}
+}
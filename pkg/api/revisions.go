@@ -0,0 +1,198 @@
+package api
+
+import (
+	"GoNews/pkg/auth"
+	"GoNews/pkg/diff"
+	"GoNews/pkg/storage"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Регистрация обработчиков истории изменений публикаций.
+// История доступна только после аутентификации.
+func (api *API) revisionEndpoints() {
+	api.router.HandleFunc("/posts/{id:[0-9]+}/revisions", requireScope(auth.ScopeRead, api.revisionsHandler)).Methods(http.MethodGet, http.MethodOptions)
+	api.router.HandleFunc("/posts/{id:[0-9]+}/revisions/{n:[0-9]+}", requireScope(auth.ScopeRead, api.revisionHandler)).Methods(http.MethodGet, http.MethodOptions)
+	api.router.HandleFunc("/posts/{id:[0-9]+}/revisions/{n:[0-9]+}/revert", requireScope(auth.ScopeWrite, api.revertHandler)).Methods(http.MethodPost, http.MethodOptions)
+	api.router.HandleFunc("/posts/{id:[0-9]+}/diff", requireScope(auth.ScopeRead, api.diffHandler)).Methods(http.MethodGet, http.MethodOptions)
+}
+
+// revisionDiff - построчная разница между двумя ревизиями публикации.
+type revisionDiff struct {
+	PostID  int
+	From    int
+	To      int
+	Title   []diff.Line
+	Content []diff.Line
+}
+
+// Получение всех ревизий публикации по возрастанию номера.
+func (api *API) revisionsHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	revs, err := api.db.Revisions(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	bytes, err := json.Marshal(revs)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Write(bytes)
+}
+
+// Получение ревизии публикации по номеру.
+func (api *API) revisionHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	n, _ := strconv.Atoi(mux.Vars(r)["n"])
+
+	rev, err := api.db.Revision(r.Context(), id, n)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	bytes, err := json.Marshal(rev)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Write(bytes)
+}
+
+// Построчная разница между ревизиями from и to.
+// По умолчанию to - последняя ревизия, from - предыдущая перед to.
+// Ревизия 0 означает пустую публикацию: разница с ней показывает
+// публикацию в момент создания.
+// Слишком большие ревизии не сравниваются, см. diff.MaxLines.
+func (api *API) diffHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	var validationErrors []string
+	from, to := -1, -1
+	if v := r.URL.Query().Get("from"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			validationErrors = append(validationErrors, "from должен быть неотрицательным числом")
+		}
+		from = n
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			validationErrors = append(validationErrors, "to должен быть неотрицательным числом")
+		}
+		to = n
+	}
+	if len(validationErrors) > 0 {
+		writeErrors(w, http.StatusBadRequest, validationErrors...)
+		return
+	}
+
+	revs, err := api.db.Revisions(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if to < 0 {
+		to = len(revs)
+	}
+	if from < 0 {
+		from = to - 1
+		if from < 0 {
+			from = 0
+		}
+	}
+
+	// Ревизии пронумерованы подряд начиная с 1.
+	revision := func(n int) (storage.Revision, bool) {
+		if n == 0 {
+			return storage.Revision{PostID: id}, true
+		}
+		if n > len(revs) {
+			return storage.Revision{}, false
+		}
+		return revs[n-1], true
+	}
+	a, okFrom := revision(from)
+	b, okTo := revision(to)
+	if !okFrom || !okTo {
+		writeErrors(w, http.StatusNotFound, "ревизия не найдена")
+		return
+	}
+
+	d := revisionDiff{PostID: id, From: from, To: to}
+	d.Title, err = diff.Lines(a.Title, b.Title)
+	if err == nil {
+		d.Content, err = diff.Lines(a.Content, b.Content)
+	}
+	if errors.Is(err, diff.ErrTooLarge) {
+		writeErrors(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	bytes, err := json.Marshal(d)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Write(bytes)
+}
+
+// Возврат публикации к ревизии n.
+// Заголовок, содержание и автор берутся из ревизии, а само изменение
+// сохраняется как новая ревизия, поэтому возврат тоже можно отменить.
+// Необязательный заголовок If-Match ограничивает возврат версией публикации.
+func (api *API) revertHandler(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	n, _ := strconv.Atoi(mux.Vars(r)["n"])
+
+	var version int
+	if r.Header.Get("If-Match") != "" {
+		var ok bool
+		if version, ok = ifMatchVersion(w, r); !ok {
+			return
+		}
+	}
+
+	rev, err := api.db.Revision(r.Context(), id, n)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	update := storage.Post{
+		ID:       id,
+		Title:    rev.Title,
+		Content:  rev.Content,
+		AuthorID: rev.AuthorID,
+		Version:  version,
+	}
+	current, err := api.db.Post(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if !canUpdatePost(principal(r), current, update) {
+		forbidden(w, "автор может изменять только свои публикации")
+		return
+	}
+	if update.Version == 0 {
+		update.Version = current.Version
+	}
+
+	err = api.db.UpdatePost(r.Context(), update)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
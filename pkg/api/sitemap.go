@@ -0,0 +1,126 @@
+package api
+
+import (
+	"GoNews/pkg/feed"
+	"GoNews/pkg/sitemap"
+	"GoNews/pkg/storage"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Регистрация обработчиков карт сайта.
+func (api *API) sitemapEndpoints() {
+	api.router.HandleFunc("/sitemap.xml", api.sitemapHandler).Methods(http.MethodGet, http.MethodOptions)
+	api.router.HandleFunc("/sitemap-{n:[0-9]+}.xml", api.sitemapPartHandler).Methods(http.MethodGet, http.MethodOptions)
+	api.router.HandleFunc("/sitemap-news.xml", api.newsSitemapHandler).Methods(http.MethodGet, http.MethodOptions)
+}
+
+// sitemapFilter отбирает опубликованные публикации в постоянном порядке,
+// чтобы части карты сайта не пересекались.
+var sitemapFilter = storage.PostFilter{Status: storage.StatusPublished, SortBy: storage.SortByID}
+
+// Карта сайта со всеми опубликованными публикациями.
+// Если публикаций больше sitemap.MaxURLs, возвращается индекс
+// со ссылками на части карты /sitemap-N.xml.
+func (api *API) sitemapHandler(w http.ResponseWriter, r *http.Request) {
+	// Сначала узнаём только количество публикаций: для индекса
+	// сами публикации не нужны, а их может быть очень много.
+	_, total, err := api.db.Posts(r.Context(), sitemapFilter, 1, 0)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if total <= sitemap.MaxURLs {
+		api.writeSitemapPart(w, r, 1)
+		return
+	}
+
+	base := api.baseURL(r)
+	var parts []sitemap.Sitemap
+	for n := 1; (n-1)*sitemap.MaxURLs < total; n++ {
+		parts = append(parts, sitemap.Sitemap{Loc: fmt.Sprintf("%s/sitemap-%d.xml", base, n)})
+	}
+	bytes, err := sitemap.Index(parts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	// Индекс зависит только от количества публикаций, поэтому
+	// момент изменения неизвестен и ответ проверяется по ETag.
+	api.writeCached(w, r, bytes, "", 0)
+}
+
+// Часть N карты сайта, начиная с 1.
+func (api *API) sitemapPartHandler(w http.ResponseWriter, r *http.Request) {
+	n, _ := strconv.Atoi(mux.Vars(r)["n"])
+	if n < 1 {
+		writeErrors(w, http.StatusNotFound, "часть карты сайта не найдена")
+		return
+	}
+	api.writeSitemapPart(w, r, n)
+}
+
+// writeSitemapPart отправляет часть n карты сайта: не больше
+// sitemap.MaxURLs публикаций, загружается только эта страница.
+func (api *API) writeSitemapPart(w http.ResponseWriter, r *http.Request, n int) {
+	posts, _, err := api.db.Posts(r.Context(), sitemapFilter, sitemap.MaxURLs, (n-1)*sitemap.MaxURLs)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(posts) == 0 && n > 1 {
+		writeErrors(w, http.StatusNotFound, "часть карты сайта не найдена")
+		return
+	}
+
+	bytes, err := sitemap.URLSet(api.sitemapURLs(r, posts))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	api.writeCached(w, r, bytes, "", lastModified(posts...))
+}
+
+// Карта новостей Google News: публикации за последние sitemap.NewsWindow.
+func (api *API) newsSitemapHandler(w http.ResponseWriter, r *http.Request) {
+	filter := storage.PostFilter{
+		Status:         storage.StatusPublished,
+		PublishedAfter: time.Now().Add(-sitemap.NewsWindow).Unix(),
+		SortBy:         storage.SortByPublishedAt,
+		SortDesc:       true,
+	}
+	posts, _, err := api.db.Posts(r.Context(), filter, sitemap.MaxNewsURLs, 0)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	base := api.baseURL(r)
+	news := make([]sitemap.News, 0, len(posts))
+	for _, p := range posts {
+		news = append(news, sitemap.News{Loc: feed.PostURL(base, p.ID), Title: p.Title, Published: p.PublishedAt})
+	}
+	bytes, err := sitemap.NewsSet(sitemap.Publication{Name: "GoNews", Language: "ru"}, news)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	api.writeCached(w, r, bytes, "", lastModified(posts...))
+}
+
+// sitemapURLs возвращает адреса публикаций для карты сайта.
+func (api *API) sitemapURLs(r *http.Request, posts []storage.Post) []sitemap.URL {
+	base := api.baseURL(r)
+	urls := make([]sitemap.URL, 0, len(posts))
+	for _, p := range posts {
+		urls = append(urls, sitemap.URL{Loc: feed.PostURL(base, p.ID), LastMod: lastModified(p)})
+	}
+	return urls
+}
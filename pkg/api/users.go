@@ -0,0 +1,299 @@
+package api
+
+import (
+	"GoNews/pkg/auth"
+	"GoNews/pkg/storage"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Регистрация обработчиков учётных записей пользователей.
+// Регистрация, вход и сброс пароля доступны без аутентификации, см. publicPaths.
+func (api *API) userEndpoints() {
+	api.router.HandleFunc("/auth/register", api.registerHandler).Methods(http.MethodPost, http.MethodOptions)
+	api.router.HandleFunc("/auth/login", api.loginHandler).Methods(http.MethodPost, http.MethodOptions)
+	api.router.HandleFunc("/auth/password", api.changePasswordHandler).Methods(http.MethodPost, http.MethodOptions)
+	api.router.HandleFunc("/auth/password/reset", api.requestResetHandler).Methods(http.MethodPost, http.MethodOptions)
+	api.router.HandleFunc("/auth/password/reset/confirm", api.confirmResetHandler).Methods(http.MethodPost, http.MethodOptions)
+}
+
+// publicPaths - изменяющие запросы, которые выполняются без аутентификации.
+var publicPaths = map[string]bool{
+	"/auth/register":               true,
+	"/auth/login":                  true,
+	"/auth/password/reset":         true,
+	"/auth/password/reset/confirm": true,
+}
+
+// OnPasswordReset задаёт функцию доставки токена сброса пароля пользователю,
+// например письмом. Без неё запрошенный сброс пароля нельзя завершить.
+func (api *API) OnPasswordReset(fn func(u storage.User, token string)) {
+	api.onPasswordReset = fn
+}
+
+// userResponse - пользователь в ответе. Хеши пароля и токена сброса
+// клиенту не передаются.
+type userResponse struct {
+	ID        int
+	Email     string
+	Name      string
+	Role      string
+	AuthorID  int
+	CreatedAt int64
+}
+
+func newUserResponse(u storage.User) userResponse {
+	return userResponse{ID: u.ID, Email: u.Email, Name: u.Name, Role: u.Role, AuthorID: u.AuthorID, CreatedAt: u.CreatedAt}
+}
+
+// userSubjectPrefix - префикс Principal.Subject пользователей, вошедших по паролю.
+const userSubjectPrefix = "user:"
+
+// userPrincipal возвращает клиента, от имени которого действует пользователь u.
+func userPrincipal(u storage.User) auth.Principal {
+	return auth.Principal{
+		Subject:      userSubjectPrefix + strconv.Itoa(u.ID),
+		Name:         u.Name,
+		Scopes:       []string{auth.ScopeRead, auth.ScopeWrite},
+		Role:         u.Role,
+		AuthorID:     u.AuthorID,
+		TokenVersion: u.TokenVersion,
+	}
+}
+
+// userID возвращает ID пользователя, выполняющего запрос.
+// ok == false, если запрос выполняет не пользователь, вошедший по паролю.
+func userID(r *http.Request) (id int, ok bool) {
+	return subjectUserID(principal(r).Subject)
+}
+
+// subjectUserID возвращает ID пользователя по Principal.Subject.
+// ok == false, если subject принадлежит не пользователю сервера.
+func subjectUserID(subject string) (id int, ok bool) {
+	if !strings.HasPrefix(subject, userSubjectPrefix) {
+		return 0, false
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(subject, userSubjectPrefix))
+	return id, err == nil
+}
+
+// normalizeEmail приводит адрес электронной почты к виду, в котором он хранится.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Регистрация пользователя. Тело запроса: {"Email": "...", "Name": "...", "Password": "..."}.
+// Вместе с пользователем создаётся автор Name, новый пользователь получает роль author.
+func (api *API) registerHandler(w http.ResponseWriter, r *http.Request) {
+	if !api.cfg.Auth.Users.Registration {
+		writeErrors(w, http.StatusForbidden, "регистрация пользователей отключена")
+		return
+	}
+
+	var req struct {
+		Email    string
+		Name     string
+		Password string
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, "некорректное тело запроса: "+err.Error())
+		return
+	}
+
+	var validationErrors []string
+	email := normalizeEmail(req.Email)
+	if !strings.Contains(email, "@") {
+		validationErrors = append(validationErrors, "некорректный адрес электронной почты")
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		validationErrors = append(validationErrors, "имя не может быть пустым")
+	}
+	if err := auth.ValidatePassword(req.Password); err != nil {
+		validationErrors = append(validationErrors, err.Error())
+	}
+	if len(validationErrors) > 0 {
+		writeErrors(w, http.StatusBadRequest, validationErrors...)
+		return
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	u, err := api.db.AddUser(r.Context(), storage.User{
+		Email:        email,
+		Name:         strings.TrimSpace(req.Name),
+		PasswordHash: hash,
+		Role:         auth.RoleAuthor,
+		CreatedAt:    time.Now().Unix(),
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newUserResponse(u))
+}
+
+// Вход по адресу и паролю. Тело запроса: {"Email": "...", "Password": "..."}.
+// В ответе возвращается JWT для заголовка Authorization: Bearer и момент его истечения.
+func (api *API) loginHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email    string
+		Password string
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, "некорректное тело запроса: "+err.Error())
+		return
+	}
+
+	u, err := api.db.UserByEmail(r.Context(), normalizeEmail(req.Email))
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		writeError(w, err)
+		return
+	}
+	// Для неизвестного адреса пароль тоже проверяется, см. auth.CheckPassword.
+	if !auth.CheckPassword(u.PasswordHash, req.Password) {
+		unauthorized(w, false, "неверный адрес электронной почты или пароль")
+		return
+	}
+
+	token, expiresAt, err := api.jwt.Issue(userPrincipal(u), time.Now(), api.cfg.Auth.JWT.TokenTTL)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(struct {
+		Token     string
+		ExpiresAt int64
+	}{token, expiresAt.Unix()})
+}
+
+// Смена пароля пользователем, вошедшим по паролю.
+// Тело запроса: {"OldPassword": "...", "NewPassword": "..."}.
+// Выпущенные пользователю токены, в том числе токен запроса, перестают действовать.
+func (api *API) changePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := userID(r)
+	if !ok {
+		forbidden(w, "сменить пароль может только пользователь, вошедший по паролю")
+		return
+	}
+
+	var req struct {
+		OldPassword string
+		NewPassword string
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, "некорректное тело запроса: "+err.Error())
+		return
+	}
+	if err := auth.ValidatePassword(req.NewPassword); err != nil {
+		writeErrors(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	u, err := api.db.User(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if !auth.CheckPassword(u.PasswordHash, req.OldPassword) {
+		forbidden(w, "неверный текущий пароль")
+		return
+	}
+
+	api.setPassword(w, r, u.ID, req.NewPassword)
+}
+
+// Запрос сброса пароля. Тело запроса: {"Email": "..."}.
+// Токен сброса доставляется пользователю функцией OnPasswordReset.
+// Ответ не зависит от того, зарегистрирован ли адрес.
+func (api *API) requestResetHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, "некорректное тело запроса: "+err.Error())
+		return
+	}
+
+	u, err := api.db.UserByEmail(r.Context(), normalizeEmail(req.Email))
+	if errors.Is(err, storage.ErrNotFound) {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	token, hash, err := auth.NewResetToken()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	expiresAt := time.Now().Add(api.cfg.Auth.Users.ResetTTL).Unix()
+	if err := api.db.SetResetToken(r.Context(), u.ID, hash, expiresAt); err != nil {
+		writeError(w, err)
+		return
+	}
+	if api.onPasswordReset != nil {
+		api.onPasswordReset(u, token)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Завершение сброса пароля. Тело запроса: {"Token": "...", "Password": "..."}.
+// Токен действует один раз и не дольше auth.users.reset_ttl.
+// Выпущенные пользователю JWT перестают действовать.
+func (api *API) confirmResetHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token    string
+		Password string
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, "некорректное тело запроса: "+err.Error())
+		return
+	}
+	if err := auth.ValidatePassword(req.Password); err != nil {
+		writeErrors(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	u, err := api.db.UserByResetToken(r.Context(), auth.HashToken(req.Token))
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		writeError(w, err)
+		return
+	}
+	if err != nil || time.Now().Unix() >= u.ResetExpiresAt {
+		writeErrors(w, http.StatusBadRequest, "токен сброса пароля недействителен или просрочен")
+		return
+	}
+
+	api.setPassword(w, r, u.ID, req.Password)
+}
+
+// setPassword сохраняет новый пароль пользователя и отправляет ответ.
+func (api *API) setPassword(w http.ResponseWriter, r *http.Request, id int, password string) {
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := api.db.SetPassword(r.Context(), id, hash); err != nil {
+		writeError(w, fmt.Errorf("не удалось сменить пароль: %w", err))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"GoNews/pkg/storage"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Префиксы отличают секреты GoNews от других секретов,
+// например при поиске утёкших ключей в репозиториях.
+const (
+	apiKeyPrefix     = "gn_"
+	resetTokenPrefix = "gnr_"
+)
+
+// NewAPIKey выпускает новый ключ API и возвращает его вместе с хешем,
+// который сохраняется в хранилище вместо самого ключа.
+func NewAPIKey() (key, hash string, err error) {
+	return newToken(apiKeyPrefix)
+}
+
+// NewResetToken выпускает токен сброса пароля и возвращает его вместе с хешем.
+func NewResetToken() (token, hash string, err error) {
+	return newToken(resetTokenPrefix)
+}
+
+// newToken возвращает случайный токен с префиксом prefix и его хеш.
+func newToken(prefix string) (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("не удалось сгенерировать токен: %w", err)
+	}
+	token = prefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// HashToken возвращает SHA-256 ключа API или токена сброса пароля
+// в шестнадцатеричном виде. Токены содержат 256 случайных бит,
+// поэтому соль и медленный хеш не нужны.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueAPIKey выпускает ключ API с именем, разрешениями, ролью и автором из k
+// и сохраняет его хеш в хранилище. Без роли ключ получает наименьшую,
+// author: без автора такой ключ не может изменять публикации.
+// Возвращает сам ключ и сохранённую запись о нём.
+func IssueAPIKey(ctx context.Context, db storage.Interface, k storage.APIKey) (string, storage.APIKey, error) {
+	if k.Role == "" {
+		k.Role = RoleAuthor
+	}
+	if !ValidRole(k.Role) {
+		return "", storage.APIKey{}, fmt.Errorf("неизвестная роль %q: %w", k.Role, storage.ErrInvalid)
+	}
+	if k.AuthorID != 0 {
+		_, err := db.Author(ctx, k.AuthorID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return "", storage.APIKey{}, fmt.Errorf("автор с ID %d: %w", k.AuthorID, storage.ErrAuthorNotFound)
+		}
+		if err != nil {
+			return "", storage.APIKey{}, err
+		}
+	}
+
+	key, hash, err := NewAPIKey()
+	if err != nil {
+		return "", storage.APIKey{}, err
+	}
+
+	k.Hash, k.CreatedAt, k.RevokedAt = hash, time.Now().Unix(), 0
+	k.ID, err = db.AddAPIKey(ctx, k)
+	if err != nil {
+		return "", storage.APIKey{}, err
+	}
+
+	return key, k, nil
+}
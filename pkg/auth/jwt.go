@@ -0,0 +1,334 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JWTOptions - параметры проверки JWT.
+type JWTOptions struct {
+	Secret    []byte         // общий секрет HS256, пустой - HS256 не принимается
+	PublicKey *rsa.PublicKey // открытый ключ RS256, nil - RS256 не принимается
+	// KeyFunc возвращает открытый ключ RS256 по идентификатору kid из заголовка
+	// токена, например из набора JWKS. Используется вместо PublicKey.
+	KeyFunc  func(kid string) (*rsa.PublicKey, error)
+	Issuer   string        // ожидаемое значение iss, пустое - не проверяется
+	Audience string        // ожидаемое значение aud, пустое - не проверяется
+	Leeway   time.Duration // допустимое расхождение часов при проверке exp и nbf
+}
+
+// MinSecretLength - наименьшая длина секрета HS256 в байтах: RFC 7518
+// требует ключ не короче результата хеш-функции, 256 бит.
+const MinSecretLength = 32
+
+// placeholderSecrets - части значений-заглушек из примеров конфигурации.
+var placeholderSecrets = []string{"change-me", "changeme", "change_me", "replace-me", "your-secret", "example", "secret-key"}
+
+// CheckSecret проверяет, что секрет HS256 достаточно длинный и не является
+// заглушкой из примера конфигурации: зная секрет, любой может выпустить
+// себе токен с любой ролью.
+func CheckSecret(secret string) error {
+	if len(secret) < MinSecretLength {
+		return fmt.Errorf("секрет HS256 короче %d байт", MinSecretLength)
+	}
+	lower := strings.ToLower(secret)
+	for _, p := range placeholderSecrets {
+		if strings.Contains(lower, p) {
+			return fmt.Errorf("секрет HS256 похож на заглушку из примера конфигурации")
+		}
+	}
+	if strings.Count(secret, secret[:1]) == len(secret) {
+		return fmt.Errorf("секрет HS256 состоит из одного повторяющегося символа")
+	}
+	return nil
+}
+
+// JWT проверяет JSON Web Token (RFC 7519), подписанные HS256 или RS256.
+type JWT struct {
+	opts JWTOptions
+}
+
+// NewJWT создаёт объект проверки JWT.
+func NewJWT(opts JWTOptions) *JWT {
+	return &JWT{opts: opts}
+}
+
+// ParseRSAPublicKey разбирает открытый ключ RSA в формате PEM:
+// PUBLIC KEY (PKIX) или RSA PUBLIC KEY (PKCS #1).
+func ParseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("открытый ключ не в формате PEM")
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось разобрать открытый ключ: %w", err)
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("открытый ключ не является ключом RSA")
+		}
+		return rsaKey, nil
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось разобрать открытый ключ: %w", err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип PEM-блока %q", block.Type)
+	}
+}
+
+// header - заголовок JWT.
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// registeredClaims - стандартные поля JWT, которые проверяет Decode.
+type registeredClaims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+}
+
+// claims - поля полезной нагрузки токенов, которые выдаются клиентам API.
+type claims struct {
+	registeredClaims
+	Name     string  `json:"name"`
+	Scope    *string `json:"scope"`
+	Role     string  `json:"role"`
+	AuthorID int     `json:"author_id"`
+	Version  int     `json:"token_version"`
+}
+
+// audience - поле aud: строка или массив строк.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*a = audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+func (a audience) contains(s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Issue выпускает для клиента p токен HS256, действующий ttl с момента now.
+// Поля iss и aud заполняются ожидаемыми значениями, чтобы токен проходил Verify.
+func (j *JWT) Issue(p Principal, now time.Time, ttl time.Duration) (token string, expiresAt time.Time, err error) {
+	if len(j.opts.Secret) == 0 {
+		return "", time.Time{}, fmt.Errorf("выдача токенов недоступна: не задан секрет HS256")
+	}
+
+	expiresAt = now.Add(ttl)
+	c := map[string]interface{}{
+		"sub": p.Subject,
+		"iat": now.Unix(),
+		"exp": expiresAt.Unix(),
+	}
+	if p.Name != "" {
+		c["name"] = p.Name
+	}
+	if p.Role != "" {
+		c["role"] = p.Role
+	}
+	if p.AuthorID != 0 {
+		c["author_id"] = p.AuthorID
+	}
+	if p.TokenVersion != 0 {
+		c["token_version"] = p.TokenVersion
+	}
+	if p.Scopes != nil {
+		c["scope"] = strings.Join(p.Scopes, " ")
+	}
+	if j.opts.Issuer != "" {
+		c["iss"] = j.opts.Issuer
+	}
+	if j.opts.Audience != "" {
+		c["aud"] = j.opts.Audience
+	}
+
+	h, err := json.Marshal(header{Alg: "HS256", Typ: "JWT"})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, j.opts.Secret)
+	mac.Write([]byte(signed))
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), expiresAt, nil
+}
+
+// Verify проверяет подпись и срок действия токена и возвращает клиента,
+// которому он выдан. Токен обязан содержать sub и exp. Разрешения клиента
+// берутся из поля scope (через пробел, как в OAuth 2.0), роль - из поля role,
+// ID автора, от имени которого действует клиент, - из поля author_id,
+// версия учётных данных - из поля token_version.
+// Отсутствующие поля прав не дают: без scope токен разрешает только чтение,
+// без role клиент не может изменять публикации.
+// Все ошибки проверки оборачивают ErrUnauthenticated.
+func (j *JWT) Verify(token string, now time.Time) (Principal, error) {
+	var c claims
+	if err := j.Decode(token, now, &c); err != nil {
+		return Principal{}, err
+	}
+	if c.Role != "" && !ValidRole(c.Role) {
+		return Principal{}, fmt.Errorf("%w: неизвестная роль %q", ErrUnauthenticated, c.Role)
+	}
+
+	scopes := []string{ScopeRead}
+	if c.Scope != nil {
+		scopes = strings.Fields(*c.Scope)
+	}
+
+	return Principal{
+		Subject:      c.Subject,
+		Name:         c.Name,
+		Scopes:       scopes,
+		Role:         c.Role,
+		AuthorID:     c.AuthorID,
+		TokenVersion: c.Version,
+	}, nil
+}
+
+// Decode проверяет подпись токена, sub, exp, nbf, iss и aud и декодирует
+// полезную нагрузку в v. Позволяет прочитать поля, которые не входят
+// в Principal, например поля ID-токена OpenID Connect.
+// Все ошибки проверки оборачивают ErrUnauthenticated.
+func (j *JWT) Decode(token string, now time.Time, v interface{}) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("%w: токен не в формате JWS Compact", ErrUnauthenticated)
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return fmt.Errorf("%w: заголовок токена: %v", ErrUnauthenticated, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("%w: подпись токена: %v", ErrUnauthenticated, err)
+	}
+	if err := j.verifySignature(h, parts[0]+"."+parts[1], signature); err != nil {
+		return err
+	}
+
+	var c registeredClaims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return fmt.Errorf("%w: полезная нагрузка токена: %v", ErrUnauthenticated, err)
+	}
+	if err := j.validate(c, now); err != nil {
+		return err
+	}
+	if err := decodeSegment(parts[1], v); err != nil {
+		return fmt.Errorf("%w: полезная нагрузка токена: %v", ErrUnauthenticated, err)
+	}
+
+	return nil
+}
+
+// rsaKey возвращает открытый ключ RS256 для токена с идентификатором kid.
+func (j *JWT) rsaKey(kid string) (*rsa.PublicKey, error) {
+	if j.opts.KeyFunc != nil {
+		return j.opts.KeyFunc(kid)
+	}
+	return j.opts.PublicKey, nil
+}
+
+// verifySignature проверяет подпись алгоритмом из заголовка токена.
+// Принимаются только алгоритмы, для которых настроен ключ, поэтому
+// токен нельзя подписать открытым ключом RS256 как секретом HS256.
+func (j *JWT) verifySignature(h header, signed string, signature []byte) error {
+	alg := h.Alg
+	switch {
+	case alg == "HS256" && len(j.opts.Secret) > 0:
+		mac := hmac.New(sha256.New, j.opts.Secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("%w: неверная подпись токена", ErrUnauthenticated)
+		}
+	case alg == "RS256" && (j.opts.PublicKey != nil || j.opts.KeyFunc != nil):
+		key, err := j.rsaKey(h.Kid)
+		if err != nil {
+			return fmt.Errorf("%w: ключ подписи: %v", ErrUnauthenticated, err)
+		}
+		digest := sha256.Sum256([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("%w: неверная подпись токена", ErrUnauthenticated)
+		}
+	default:
+		return fmt.Errorf("%w: алгоритм подписи %q не принимается", ErrUnauthenticated, alg)
+	}
+
+	return nil
+}
+
+// validate проверяет поля полезной нагрузки токена.
+func (j *JWT) validate(c registeredClaims, now time.Time) error {
+	leeway := j.opts.Leeway.Seconds()
+	unix := float64(now.Unix())
+
+	if c.Subject == "" {
+		return fmt.Errorf("%w: в токене нет sub", ErrUnauthenticated)
+	}
+	if c.ExpiresAt == nil {
+		return fmt.Errorf("%w: в токене нет exp", ErrUnauthenticated)
+	}
+	if unix >= *c.ExpiresAt+leeway {
+		return fmt.Errorf("%w: срок действия токена истёк", ErrUnauthenticated)
+	}
+	if c.NotBefore != nil && unix < *c.NotBefore-leeway {
+		return fmt.Errorf("%w: токен ещё не действителен", ErrUnauthenticated)
+	}
+	if j.opts.Issuer != "" && c.Issuer != j.opts.Issuer {
+		return fmt.Errorf("%w: токен выдан другим издателем", ErrUnauthenticated)
+	}
+	if j.opts.Audience != "" && !c.Audience.contains(j.opts.Audience) {
+		return fmt.Errorf("%w: токен выдан для другого получателя", ErrUnauthenticated)
+	}
+	return nil
+}
+
+// decodeSegment декодирует сегмент токена: JSON в base64url без дополнения.
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
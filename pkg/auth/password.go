@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Ограничения длины пароля в байтах. bcrypt учитывает только первые 72 байта,
+// поэтому более длинные пароли отклоняются, а не обрезаются незаметно.
+const (
+	MinPasswordLen = 8
+	MaxPasswordLen = 72
+)
+
+// dummyPasswordHash сравнивается с паролем, когда пользователь не найден,
+// чтобы по времени ответа нельзя было узнать, зарегистрирован ли адрес.
+const dummyPasswordHash = "$2a$10$e7Mn9X8JABY6M3i1bOf.T.cJuCk.4OOvOmAmtSri6z2Cyu9hcd.Ri"
+
+// ValidatePassword проверяет, что пароль допустимой длины.
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLen {
+		return fmt.Errorf("пароль должен содержать не меньше %d символов", MinPasswordLen)
+	}
+	if len(password) > MaxPasswordLen {
+		return fmt.Errorf("пароль должен занимать не больше %d байт", MaxPasswordLen)
+	}
+	return nil
+}
+
+// HashPassword возвращает хеш пароля bcrypt.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("не удалось вычислить хеш пароля: %w", err)
+	}
+	return string(hash), nil
+}
+
+// CheckPassword сообщает, соответствует ли пароль хешу bcrypt.
+// Пустой хеш (пользователь не найден) проверяется с той же задержкой,
+// что и настоящий, и никогда не совпадает.
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword([]byte(dummyPasswordHash), []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
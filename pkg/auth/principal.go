@@ -0,0 +1,47 @@
+// Package auth проверяет учётные данные клиентов API и передаёт
+// сведения об аутентифицированном клиенте в контексте запроса.
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrUnauthenticated - учётные данные отсутствуют, некорректны или просрочены.
+var ErrUnauthenticated = errors.New("ошибка аутентификации")
+
+// Principal - аутентифицированный клиент API.
+type Principal struct {
+	Subject  string   // постоянный идентификатор клиента
+	Name     string   // отображаемое имя, может быть пустым
+	Scopes   []string // разрешения клиента, см. HasScope
+	Role     string   // роль клиента, см. HasRole; пустая - без роли
+	AuthorID int      // ID автора, от имени которого действует клиент, 0 - не автор
+	// TokenVersion - версия учётных данных, для которой выпущен токен,
+	// см. storage.User.TokenVersion. Для клиентов, не являющихся
+	// пользователями сервера, всегда 0.
+	TokenVersion int
+}
+
+// DisplayName возвращает имя клиента для журналов и истории изменений:
+// Name, а если оно не задано - Subject.
+func (p Principal) DisplayName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Subject
+}
+
+type principalKey struct{}
+
+// WithPrincipal возвращает контекст с аутентифицированным клиентом.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom возвращает аутентифицированного клиента из контекста.
+// ok == false, если запрос выполняется без аутентификации.
+func PrincipalFrom(ctx context.Context) (p Principal, ok bool) {
+	p, ok = ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,27 @@
+package auth
+
+// Роли клиентов API. Каждая следующая включает права предыдущих.
+const (
+	RoleAuthor = "author" // изменяет только свои публикации
+	RoleEditor = "editor" // изменяет любые публикации, публикует и восстанавливает их, добавляет и переименовывает авторов
+	RoleAdmin  = "admin"  // кроме того, удаляет авторов и управляет ключами API
+)
+
+// roleLevels упорядочивает роли по возрастанию прав.
+var roleLevels = map[string]int{
+	RoleAuthor: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+// ValidRole сообщает, что role - известная роль.
+func ValidRole(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+// HasRole сообщает, что роль клиента не ниже role.
+func (p Principal) HasRole(role string) bool {
+	have, ok := roleLevels[p.Role]
+	return ok && have >= roleLevels[role]
+}
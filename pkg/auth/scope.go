@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Разрешения клиентов API. Каждое следующее включает предыдущие.
+const (
+	ScopeRead  = "read"  // чтение, в том числе черновиков, корзины и истории изменений
+	ScopeWrite = "write" // создание, изменение и удаление данных
+	ScopeAdmin = "admin" // управление ключами API
+)
+
+// scopeLevels упорядочивает разрешения по возрастанию.
+var scopeLevels = map[string]int{
+	ScopeRead:  1,
+	ScopeWrite: 2,
+	ScopeAdmin: 3,
+}
+
+// ParseScopes разбирает список разрешений, разделённых запятыми или пробелами.
+func ParseScopes(s string) ([]string, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("не задано ни одного разрешения")
+	}
+	for _, f := range fields {
+		if _, ok := scopeLevels[f]; !ok {
+			return nil, fmt.Errorf("неизвестное разрешение %q, допустимы %s, %s и %s", f, ScopeRead, ScopeWrite, ScopeAdmin)
+		}
+	}
+	return fields, nil
+}
+
+// HasScope сообщает, есть ли у клиента разрешение scope
+// или включающее его более широкое разрешение.
+func (p Principal) HasScope(scope string) bool {
+	need := scopeLevels[scope]
+	for _, s := range p.Scopes {
+		if level, ok := scopeLevels[s]; ok && level >= need {
+			return true
+		}
+	}
+	return false
+}
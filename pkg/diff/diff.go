@@ -0,0 +1,236 @@
+// Package diff строит построчную разницу между двумя текстами.
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Виды строк разницы.
+const (
+	Equal  = " " // строка есть в обоих текстах
+	Insert = "+" // строка добавлена во втором тексте
+	Delete = "-" // строка удалена из первого текста
+)
+
+// MaxLines - наибольшее суммарное количество строк сравниваемых текстов.
+// Время сравнения растёт как произведение числа строк на число различий,
+// поэтому слишком большие тексты не сравниваются.
+const MaxLines = 10000
+
+// ErrTooLarge возвращается, если тексты содержат больше MaxLines строк.
+var ErrTooLarge = errors.New("тексты слишком велики для сравнения")
+
+// Line - строка разницы.
+type Line struct {
+	Op   string // Equal, Insert или Delete
+	Text string
+}
+
+// Lines возвращает построчную разницу между текстами a и b: минимальный
+// набор удалённых и добавленных строк, найденный алгоритмом Майерса
+// в линейной памяти. Удаления выводятся перед добавлениями.
+func Lines(a, b string) ([]Line, error) {
+	x, y := split(a), split(b)
+	if len(x)+len(y) > MaxLines {
+		return nil, fmt.Errorf("%d строк, допустимо не более %d: %w", len(x)+len(y), MaxLines, ErrTooLarge)
+	}
+
+	// Строки сравниваются много раз, поэтому заменяются номерами.
+	ids := make(map[string]int)
+	intern := func(lines []string) []int {
+		s := make([]int, len(lines))
+		for i, l := range lines {
+			id, ok := ids[l]
+			if !ok {
+				id = len(ids)
+				ids[l] = id
+			}
+			s[i] = id
+		}
+		return s
+	}
+	d := differ{x: x, y: y}
+	d.compare(intern(x), intern(y), 0, 0)
+
+	return reorder(d.lines), nil
+}
+
+// differ накапливает разницу между строками x и y.
+type differ struct {
+	x, y  []string
+	lines []Line
+}
+
+// compare добавляет разницу между участками a и b, начинающимися
+// со строк i и j текстов x и y.
+func (d *differ) compare(a, b []int, i, j int) {
+	// Общие начало и конец не участвуют в поиске.
+	head := 0
+	for head < len(a) && head < len(b) && a[head] == b[head] {
+		head++
+	}
+	for k := 0; k < head; k++ {
+		d.lines = append(d.lines, Line{Equal, d.x[i+k]})
+	}
+	a, b, i, j = a[head:], b[head:], i+head, j+head
+	tail := 0
+	for tail < len(a) && tail < len(b) && a[len(a)-1-tail] == b[len(b)-1-tail] {
+		tail++
+	}
+	a, b = a[:len(a)-tail], b[:len(b)-tail]
+
+	switch {
+	case len(a) == 0 || len(b) == 0:
+		d.replace(i, len(a), j, len(b))
+	default:
+		sa, sb, ok := bisect(a, b)
+		if ok {
+			d.compare(a[:sa], b[:sb], i, j)
+			d.compare(a[sa:], b[sb:], i+sa, j+sb)
+		} else {
+			d.replace(i, len(a), j, len(b))
+		}
+	}
+
+	for k := len(a); k < len(a)+tail; k++ {
+		d.lines = append(d.lines, Line{Equal, d.x[i+k]})
+	}
+}
+
+// replace добавляет удаление n строк x начиная с i
+// и добавление m строк y начиная с j.
+func (d *differ) replace(i, n, j, m int) {
+	for k := 0; k < n; k++ {
+		d.lines = append(d.lines, Line{Delete, d.x[i+k]})
+	}
+	for k := 0; k < m; k++ {
+		d.lines = append(d.lines, Line{Insert, d.y[j+k]})
+	}
+}
+
+// bisect находит середину кратчайшего пути редактирования a в b, встречными
+// поисками от начала и от конца. Участки не должны начинаться или
+// заканчиваться одинаковыми строками. Если у них нет общих строк,
+// возвращается ok == false.
+func bisect(a, b []int) (sa, sb int, ok bool) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	// vf[offset+k] и vb[offset+k] - наибольшее продвижение по x на диагонали k
+	// для путей от начала и от конца.
+	vf := make([]int, 2*maxD+2)
+	vb := make([]int, 2*maxD+2)
+	for k := range vf {
+		vf[k], vb[k] = -1, -1
+	}
+	vf[offset+1], vb[offset+1] = 0, 0
+
+	delta := n - m
+	// При нечётной разности длин пути встречаются при продвижении от начала.
+	front := delta%2 != 0
+	var fStart, fEnd, bStart, bEnd int
+	for d := 0; d < maxD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			kOff := offset + k
+			var x int
+			if k == -d || (k != d && vf[kOff-1] < vf[kOff+1]) {
+				x = vf[kOff+1]
+			} else {
+				x = vf[kOff-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			vf[kOff] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case front:
+				bOff := offset + delta - k
+				if bOff >= 0 && bOff < len(vb) && vb[bOff] != -1 && x >= n-vb[bOff] {
+					return splitPoint(x, y, n, m)
+				}
+			}
+		}
+		for k := -d + bStart; k <= d-bEnd; k += 2 {
+			kOff := offset + k
+			var x int
+			if k == -d || (k != d && vb[kOff-1] < vb[kOff+1]) {
+				x = vb[kOff+1]
+			} else {
+				x = vb[kOff-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x++
+				y++
+			}
+			vb[kOff] = x
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !front:
+				fOff := offset + delta - k
+				if fOff >= 0 && fOff < len(vf) && vf[fOff] != -1 {
+					fx := vf[fOff]
+					fy := fx - (fOff - offset)
+					if fx >= n-x {
+						return splitPoint(fx, fy, n, m)
+					}
+				}
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+// splitPoint возвращает точку разбиения, если она делит участки на меньшие части.
+func splitPoint(x, y, n, m int) (int, int, bool) {
+	if (x == 0 && y == 0) || (x == n && y == m) {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
+// reorder переставляет в каждом блоке изменений удаления перед добавлениями.
+func reorder(lines []Line) []Line {
+	out := make([]Line, 0, len(lines))
+	for i := 0; i < len(lines); {
+		if lines[i].Op == Equal {
+			out = append(out, lines[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(lines) && lines[j].Op != Equal {
+			j++
+		}
+		for _, op := range []string{Delete, Insert} {
+			for _, l := range lines[i:j] {
+				if l.Op == op {
+					out = append(out, l)
+				}
+			}
+		}
+		i = j
+	}
+	return out
+}
+
+// split разбивает текст на строки. Пустой текст не содержит строк.
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
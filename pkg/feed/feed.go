@@ -0,0 +1,181 @@
+// Package feed формирует ленты публикаций для подписки: RSS 2.0, Atom
+// и JSON Feed.
+package feed
+
+import (
+	"GoNews/pkg/storage"
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Channel описывает ленту в целом.
+type Channel struct {
+	Title       string
+	Description string
+	Link        string // адрес сайта
+	Self        string // адрес самой ленты
+	Next        string // адрес следующей страницы ленты, пустой - страница последняя
+	Updated     int64  // момент последнего изменения ленты (Unix)
+}
+
+// PostURL возвращает постоянный адрес публикации относительно base.
+func PostURL(base string, id int) string {
+	return strings.TrimSuffix(base, "/") + "/posts/" + strconv.Itoa(id)
+}
+
+// Представление ленты RSS 2.0.
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	Language      string     `xml:"language"`
+	LastBuildDate string     `xml:"lastBuildDate,omitempty"`
+	Links         []atomLink `xml:"atom:link"`
+	Items         []rssItem  `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	Description string  `xml:"description"`
+	Creator     string  `xml:"dc:creator,omitempty"` // в RSS поле author предназначено для адреса почты
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// RSS формирует ленту RSS 2.0 из публикаций.
+func RSS(ch Channel, posts []storage.Post) ([]byte, error) {
+	doc := rss{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:       ch.Title,
+			Link:        ch.Link,
+			Description: ch.Description,
+			Language:    "ru",
+			Links:       []atomLink{{Href: ch.Self, Rel: "self", Type: "application/rss+xml"}},
+		},
+	}
+	if ch.Next != "" {
+		doc.Channel.Links = append(doc.Channel.Links, atomLink{Href: ch.Next, Rel: "next", Type: "application/rss+xml"})
+	}
+	if ch.Updated > 0 {
+		doc.Channel.LastBuildDate = time.Unix(ch.Updated, 0).UTC().Format(time.RFC1123Z)
+	}
+	for _, p := range posts {
+		link := PostURL(ch.Link, p.ID)
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       p.Title,
+			Link:        link,
+			GUID:        rssGUID{IsPermaLink: true, Value: link},
+			Description: p.Content,
+			Creator:     p.AuthorName,
+			PubDate:     time.Unix(p.PublishedAt, 0).UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	return marshal(doc)
+}
+
+// Представление ленты Atom.
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	ID       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title     string      `xml:"title"`
+	ID        string      `xml:"id"`
+	Link      atomLink    `xml:"link"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Author    atomAuthor  `xml:"author"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Atom формирует ленту Atom из публикаций.
+func Atom(ch Channel, posts []storage.Post) ([]byte, error) {
+	doc := atomFeed{
+		Title:    ch.Title,
+		Subtitle: ch.Description,
+		ID:       ch.Self,
+		Updated:  atomTime(ch.Updated),
+		Links: []atomLink{
+			{Href: ch.Self, Rel: "self", Type: "application/atom+xml"},
+			{Href: ch.Link, Rel: "alternate"},
+		},
+	}
+	if ch.Next != "" {
+		doc.Links = append(doc.Links, atomLink{Href: ch.Next, Rel: "next", Type: "application/atom+xml"})
+	}
+	for _, p := range posts {
+		link := PostURL(ch.Link, p.ID)
+		updated := p.UpdatedAt
+		if updated < p.PublishedAt {
+			updated = p.PublishedAt
+		}
+		doc.Entries = append(doc.Entries, atomEntry{
+			Title:     p.Title,
+			ID:        link,
+			Link:      atomLink{Href: link, Rel: "alternate"},
+			Published: atomTime(p.PublishedAt),
+			Updated:   atomTime(updated),
+			Author:    atomAuthor{Name: p.AuthorName},
+			Content:   atomContent{Type: "text", Value: p.Content},
+		})
+	}
+
+	return marshal(doc)
+}
+
+// atomTime форматирует момент времени по RFC 3339, как требует Atom.
+func atomTime(t int64) string {
+	return time.Unix(t, 0).UTC().Format(time.RFC3339)
+}
+
+// marshal кодирует документ в XML с объявлением кодировки UTF-8.
+// Кириллица записывается как есть, а служебные символы XML и
+// недопустимые в XML символы экранируются или заменяются при кодировании.
+func marshal(doc interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,101 @@
+package feed
+
+import (
+	"GoNews/pkg/storage"
+	"bytes"
+	"encoding/json"
+	"html"
+	"strings"
+	"time"
+)
+
+// jsonFeedVersion - адрес спецификации JSON Feed, которой соответствует лента.
+const jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+
+// Представление ленты JSON Feed 1.1.
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description,omitempty"`
+	NextURL     string         `json:"next_url,omitempty"`
+	Language    string         `json:"language"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html"`
+	ContentText   string           `json:"content_text"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified,omitempty"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// JSON формирует ленту JSON Feed 1.1 из публикаций.
+func JSON(ch Channel, posts []storage.Post) ([]byte, error) {
+	doc := jsonFeed{
+		Version:     jsonFeedVersion,
+		Title:       ch.Title,
+		HomePageURL: ch.Link,
+		FeedURL:     ch.Self,
+		Description: ch.Description,
+		NextURL:     ch.Next,
+		Language:    "ru",
+		Items:       []jsonFeedItem{},
+	}
+	for _, p := range posts {
+		link := PostURL(ch.Link, p.ID)
+		item := jsonFeedItem{
+			ID:            link,
+			URL:           link,
+			Title:         p.Title,
+			ContentHTML:   HTML(p.Content),
+			ContentText:   p.Content,
+			DatePublished: time.Unix(p.PublishedAt, 0).UTC().Format(time.RFC3339),
+		}
+		if p.UpdatedAt > p.PublishedAt {
+			item.DateModified = time.Unix(p.UpdatedAt, 0).UTC().Format(time.RFC3339)
+		}
+		if p.AuthorName != "" {
+			item.Authors = []jsonFeedAuthor{{Name: p.AuthorName}}
+		}
+		doc.Items = append(doc.Items, item)
+	}
+
+	// Разметка в content_html и адреса с & записываются без экранирования \u003c.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// HTML преобразует текст публикации в HTML: экранирует разметку,
+// разделённые пустой строкой абзацы оборачивает в <p>,
+// а переводы строк внутри абзаца заменяет на <br>.
+func HTML(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var b strings.Builder
+	for _, para := range strings.Split(text, "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(para), "\n", "<br>"))
+		b.WriteString("</p>")
+	}
+	return b.String()
+}
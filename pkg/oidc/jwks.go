@@ -0,0 +1,121 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// keyRefreshInterval - наименьший интервал между загрузками набора ключей.
+// Провайдер меняет ключи редко, а токен с неизвестным kid не должен
+// приводить к запросу к провайдеру при каждой попытке входа.
+const keyRefreshInterval = time.Minute
+
+// errUnknownKey - в наборе ключей провайдера нет ключа с нужным kid.
+var errUnknownKey = errors.New("неизвестный ключ подписи")
+
+// jwk - ключ из набора JWKS (RFC 7517). Используются только ключи RSA.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// keySet - кешируемый набор открытых ключей провайдера. Набор загружается
+// заново, когда встречается токен с неизвестным kid: так сервер узнаёт
+// о смене ключей провайдером.
+type keySet struct {
+	uri   string
+	fetch func(ctx context.Context, u string, v interface{}) error
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func newKeySet(uri string, fetch func(ctx context.Context, u string, v interface{}) error) *keySet {
+	return &keySet{uri: uri, fetch: fetch}
+}
+
+// key возвращает ключ с идентификатором kid. Пустой kid допускается,
+// если в наборе ровно один ключ.
+func (s *keySet) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+	if !s.fetchedAt.IsZero() && time.Since(s.fetchedAt) < keyRefreshInterval {
+		return nil, errUnknownKey
+	}
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, errUnknownKey
+}
+
+// lookup ищет ключ в загруженном наборе.
+func (s *keySet) lookup(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// refresh загружает набор ключей. Ключи не RSA, ключи шифрования
+// и ключи других алгоритмов пропускаются.
+func (s *keySet) refresh(ctx context.Context) error {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	s.fetchedAt = time.Now()
+	if err := s.fetch(ctx, s.uri, &set); err != nil {
+		return fmt.Errorf("ошибка загрузки ключей провайдера OIDC: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") || (k.Alg != "" && k.Alg != "RS256") {
+			continue
+		}
+		key, err := k.rsaKey()
+		if err != nil {
+			return fmt.Errorf("ключ %q провайдера OIDC: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	s.keys = keys
+	return nil
+}
+
+// rsaKey преобразует JWK в открытый ключ RSA.
+func (k jwk) rsaKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("модуль ключа: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("экспонента ключа: %w", err)
+	}
+	exp := new(big.Int).SetBytes(e)
+	if len(n) == 0 || !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("некорректный ключ RSA")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+}
@@ -0,0 +1,250 @@
+// Package oidc реализует вход через провайдера OpenID Connect по схеме
+// authorization code с PKCE: обнаружение провайдера, обмен кода на токены
+// и проверку ID-токена по ключам JWKS.
+package oidc
+
+import (
+	"GoNews/pkg/auth"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config - параметры клиента OpenID Connect.
+type Config struct {
+	Issuer       string        // адрес провайдера, совпадает с iss в ID-токенах
+	ClientID     string        // идентификатор клиента у провайдера
+	ClientSecret string        // секрет клиента, пустой - публичный клиент (только PKCE)
+	Scopes       []string      // запрашиваемые области, openid добавляется всегда
+	Leeway       time.Duration // допустимое расхождение часов при проверке ID-токена
+}
+
+// Claims - поля ID-токена, которые использует сервер.
+type Claims struct {
+	Issuer            string `json:"iss"`
+	Subject           string `json:"sub"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	Nonce             string `json:"nonce"`
+
+	raw map[string]json.RawMessage
+}
+
+// Strings возвращает значение поля ID-токена name, которое может быть
+// строкой или массивом строк, например groups или roles.
+func (c Claims) Strings(name string) []string {
+	data, ok := c.raw[name]
+	if !ok {
+		return nil
+	}
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		return []string{one}
+	}
+	var many []string
+	json.Unmarshal(data, &many)
+	return many
+}
+
+// UnmarshalJSON сохраняет все поля ID-токена, чтобы их можно было
+// прочитать методом Strings.
+func (c *Claims) UnmarshalJSON(data []byte) error {
+	type plain Claims
+	if err := json.Unmarshal(data, (*plain)(c)); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &c.raw)
+}
+
+// metadata - документ обнаружения провайдера (OpenID Connect Discovery 1.0).
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Client - клиент провайдера OpenID Connect. Документ обнаружения
+// загружается при первом обращении, поэтому недоступность провайдера
+// не мешает запуску сервера.
+type Client struct {
+	cfg  Config
+	http *http.Client
+
+	mu       sync.Mutex
+	meta     *metadata
+	verifier *auth.JWT
+}
+
+// New создаёт клиента провайдера. httpClient позволяет задать таймауты
+// или подменить провайдера, nil - http.DefaultClient.
+func New(cfg Config, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	return &Client{cfg: cfg, http: httpClient}
+}
+
+// discover возвращает документ обнаружения провайдера и объект проверки
+// ID-токенов, загружая их при первом вызове.
+func (c *Client) discover(ctx context.Context) (*metadata, *auth.JWT, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.meta != nil {
+		return c.meta, c.verifier, nil
+	}
+
+	var meta metadata
+	if err := c.getJSON(ctx, c.cfg.Issuer+"/.well-known/openid-configuration", &meta); err != nil {
+		return nil, nil, fmt.Errorf("ошибка обнаружения провайдера OIDC: %w", err)
+	}
+	if strings.TrimSuffix(meta.Issuer, "/") != c.cfg.Issuer {
+		return nil, nil, fmt.Errorf("провайдер OIDC сообщил издателя %q вместо %q", meta.Issuer, c.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, nil, fmt.Errorf("в документе обнаружения провайдера OIDC нет обязательных адресов")
+	}
+
+	// Ключи загружаются вне контекста запроса: набор ключей общий для всех
+	// запросов, и отмена одного из них не должна прерывать его обновление.
+	keys := newKeySet(meta.JWKSURI, c.getJSON)
+	c.meta = &meta
+	c.verifier = auth.NewJWT(auth.JWTOptions{
+		KeyFunc:  func(kid string) (*rsa.PublicKey, error) { return keys.key(context.Background(), kid) },
+		Issuer:   meta.Issuer,
+		Audience: c.cfg.ClientID,
+		Leeway:   c.cfg.Leeway,
+	})
+	return c.meta, c.verifier, nil
+}
+
+// AuthCodeURL возвращает адрес страницы входа провайдера.
+// state и nonce защищают от подделки ответа, challenge - от перехвата кода (PKCE).
+func (c *Client) AuthCodeURL(ctx context.Context, redirectURL, state, nonce, challenge string) (string, error) {
+	meta, _, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	scopes := []string{"openid"}
+	for _, s := range c.cfg.Scopes {
+		if s != "openid" {
+			scopes = append(scopes, s)
+		}
+	}
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.cfg.ClientID},
+		"redirect_uri":          {redirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange обменивает код авторизации на токены и возвращает поля
+// проверенного ID-токена. nonce должен совпасть с переданным в AuthCodeURL.
+func (c *Client) Exchange(ctx context.Context, redirectURL, code, verifier, nonce string) (Claims, error) {
+	meta, idVerifier, err := c.discover(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"client_id":     {c.cfg.ClientID},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Claims{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+	}
+
+	var tokens struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := c.doJSON(req, &tokens); err != nil && tokens.Error == "" {
+		return Claims{}, fmt.Errorf("ошибка обмена кода авторизации: %w", err)
+	}
+	if tokens.Error != "" {
+		return Claims{}, fmt.Errorf("%w: провайдер OIDC отклонил код авторизации: %s %s", auth.ErrUnauthenticated, tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return Claims{}, fmt.Errorf("провайдер OIDC не вернул ID-токен")
+	}
+
+	var claims Claims
+	if err := idVerifier.Decode(tokens.IDToken, time.Now(), &claims); err != nil {
+		return Claims{}, fmt.Errorf("ID-токен: %w", err)
+	}
+	if claims.Nonce != nonce {
+		return Claims{}, fmt.Errorf("%w: nonce ID-токена не совпадает с запросом", auth.ErrUnauthenticated)
+	}
+	// При нескольких получателях токен должен быть выдан именно этому клиенту.
+	if azp := claims.Strings("azp"); len(azp) > 0 && azp[0] != c.cfg.ClientID {
+		return Claims{}, fmt.Errorf("%w: ID-токен выдан другому клиенту", auth.ErrUnauthenticated)
+	}
+
+	return claims, nil
+}
+
+// getJSON загружает документ JSON по адресу u.
+func (c *Client) getJSON(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	return c.doJSON(req, v)
+}
+
+// doJSON выполняет запрос и декодирует ответ JSON в v.
+// Тело ответа с кодом ошибки тоже декодируется: в нём может быть описание ошибки.
+func (c *Client) doJSON(req *http.Request, v interface{}) error {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	decodeErr := json.Unmarshal(body, v)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: %s", req.Method, req.URL.Redacted(), resp.Status)
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("некорректный ответ %s: %w", req.URL.Redacted(), decodeErr)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+// Package scheduler выполняет фоновые задачи сервера GoNews:
+// публикует запланированные публикации, когда наступает их срок,
+// и очищает корзину от публикаций, удалённых дольше срока хранения.
+//
+// Если одну БД используют несколько экземпляров сервера, каждая задача
+// выполняется под блокировкой хранилища (storage.Locker), поэтому в каждый
+// момент её выполняет только один экземпляр.
+package scheduler
+
+import (
+	"GoNews/pkg/storage"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// jobTimeout - предельное время одного выполнения задачи. На это же время
+// захватывается блокировка задачи.
+const jobTimeout = time.Minute
+
+// Имена блокировок фоновых задач.
+const (
+	publishLock = "scheduler.publish"
+	purgeLock   = "scheduler.purge"
+)
+
+// Options задаёт периоды фоновых задач. Нулевой период отключает задачу.
+type Options struct {
+	PublishInterval time.Duration // период публикации запланированных публикаций
+	PurgeInterval   time.Duration // период очистки корзины
+	TrashRetention  time.Duration // срок хранения публикаций в корзине, 0 - не очищать
+}
+
+// job - периодическая фоновая задача.
+type job struct {
+	lock     string
+	interval time.Duration
+	run      func(ctx context.Context) error
+}
+
+// Scheduler - планировщик фоновых задач.
+type Scheduler struct {
+	db        storage.Interface
+	retention time.Duration
+	jobs      []job
+	onPublish []func(storage.Post)
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New создаёт планировщик фоновых задач с периодами из opts.
+func New(db storage.Interface, opts Options) *Scheduler {
+	s := &Scheduler{db: db, retention: opts.TrashRetention}
+	if opts.PublishInterval > 0 {
+		s.jobs = append(s.jobs, job{lock: publishLock, interval: opts.PublishInterval, run: s.publishDue})
+	}
+	if opts.PurgeInterval > 0 && opts.TrashRetention > 0 {
+		s.jobs = append(s.jobs, job{lock: purgeLock, interval: opts.PurgeInterval, run: s.purgeTrash})
+	}
+	return s
+}
+
+// OnPublish регистрирует обработчик события публикации. Обработчик вызывается
+// для каждой публикации, переведённой планировщиком в состояние published.
+// Обработчики регистрируются до вызова Start.
+func (s *Scheduler) OnPublish(fn func(storage.Post)) {
+	s.onPublish = append(s.onPublish, fn)
+}
+
+// Start запускает фоновые задачи.
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go func(j job) {
+			defer s.wg.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+}
+
+// Stop останавливает фоновые задачи и ждёт завершения выполняющихся.
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// loop выполняет задачу раз в интервал до отмены контекста.
+func (s *Scheduler) loop(ctx context.Context, j job) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		s.runLocked(ctx, j)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runLocked выполняет задачу, если удалось захватить её блокировку.
+// Ошибки выводятся в журнал: задача будет повторена на следующем шаге.
+func (s *Scheduler) runLocked(ctx context.Context, j job) {
+	ctx, cancel := context.WithTimeout(ctx, jobTimeout)
+	defer cancel()
+
+	unlock, ok, err := s.db.TryLock(ctx, j.lock, jobTimeout)
+	if err != nil {
+		fmt.Printf("Планировщик: %s: %v\n", j.lock, err)
+		return
+	}
+	if !ok {
+		// Задачу выполняет другой экземпляр сервера.
+		return
+	}
+	defer unlock()
+
+	if err := j.run(ctx); err != nil {
+		fmt.Printf("Планировщик: %s: %v\n", j.lock, err)
+	}
+}
+
+// publishDue публикует запланированные публикации, срок которых наступил,
+// и сообщает о каждой из них обработчикам OnPublish.
+func (s *Scheduler) publishDue(ctx context.Context) error {
+	posts, err := s.db.PublishDue(ctx, time.Now().Unix())
+	for _, p := range posts {
+		for _, fn := range s.onPublish {
+			fn(p)
+		}
+	}
+	return err
+}
+
+// purgeTrash окончательно удаляет публикации, находящиеся в корзине
+// дольше срока хранения.
+func (s *Scheduler) purgeTrash(ctx context.Context) error {
+	n, err := s.db.PurgePosts(ctx, time.Now().Add(-s.retention).Unix())
+	if n > 0 {
+		fmt.Printf("Из корзины удалено публикаций: %d.\n", n)
+	}
+	return err
+}
@@ -0,0 +1,136 @@
+// Package sitemap формирует карты сайта для поисковых систем по протоколу
+// sitemaps.org и карты новостей Google News.
+package sitemap
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+const (
+	// MaxURLs - наибольшее количество адресов в одной карте сайта.
+	// Если адресов больше, карта делится на части, перечисленные в индексе.
+	MaxURLs = 50000
+	// MaxNewsURLs - наибольшее количество адресов в карте новостей.
+	MaxNewsURLs = 1000
+	// NewsWindow - новости старше этого срока не включаются в карту новостей.
+	NewsWindow = 48 * time.Hour
+)
+
+const (
+	sitemapNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	newsNS    = "http://www.google.com/schemas/sitemap-news/0.9"
+)
+
+// URL - адрес страницы в карте сайта.
+type URL struct {
+	Loc     string
+	LastMod int64 // момент последнего изменения страницы (Unix), 0 - неизвестен
+}
+
+// Sitemap - ссылка на часть карты сайта в индексе.
+type Sitemap struct {
+	Loc     string
+	LastMod int64
+}
+
+// News - новость в карте Google News.
+type News struct {
+	Loc       string
+	Title     string
+	Published int64 // момент публикации (Unix)
+}
+
+// Publication описывает издание в карте новостей.
+type Publication struct {
+	Name     string
+	Language string // код языка ISO 639
+}
+
+type urlSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	NS      string   `xml:"xmlns,attr"`
+	News    string   `xml:"xmlns:news,attr,omitempty"`
+	URLs    []urlEntry
+}
+
+type urlEntry struct {
+	XMLName xml.Name   `xml:"url"`
+	Loc     string     `xml:"loc"`
+	LastMod string     `xml:"lastmod,omitempty"`
+	News    *newsEntry `xml:"news:news,omitempty"`
+}
+
+type newsEntry struct {
+	Publication     newsPublication `xml:"news:publication"`
+	PublicationDate string          `xml:"news:publication_date"`
+	Title           string          `xml:"news:title"`
+}
+
+type newsPublication struct {
+	Name     string `xml:"news:name"`
+	Language string `xml:"news:language"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name `xml:"sitemapindex"`
+	NS       string   `xml:"xmlns,attr"`
+	Sitemaps []sitemapEntry
+}
+
+type sitemapEntry struct {
+	XMLName xml.Name `xml:"sitemap"`
+	Loc     string   `xml:"loc"`
+	LastMod string   `xml:"lastmod,omitempty"`
+}
+
+// URLSet формирует карту сайта из адресов страниц.
+func URLSet(urls []URL) ([]byte, error) {
+	doc := urlSet{NS: sitemapNS}
+	for _, u := range urls {
+		doc.URLs = append(doc.URLs, urlEntry{Loc: u.Loc, LastMod: lastMod(u.LastMod)})
+	}
+	return marshal(doc)
+}
+
+// Index формирует индекс карт сайта из ссылок на её части.
+func Index(sitemaps []Sitemap) ([]byte, error) {
+	doc := sitemapIndex{NS: sitemapNS}
+	for _, s := range sitemaps {
+		doc.Sitemaps = append(doc.Sitemaps, sitemapEntry{Loc: s.Loc, LastMod: lastMod(s.LastMod)})
+	}
+	return marshal(doc)
+}
+
+// NewsSet формирует карту новостей Google News.
+func NewsSet(pub Publication, news []News) ([]byte, error) {
+	doc := urlSet{NS: sitemapNS, News: newsNS}
+	for _, n := range news {
+		doc.URLs = append(doc.URLs, urlEntry{
+			Loc: n.Loc,
+			News: &newsEntry{
+				Publication:     newsPublication{Name: pub.Name, Language: pub.Language},
+				PublicationDate: time.Unix(n.Published, 0).UTC().Format(time.RFC3339),
+				Title:           n.Title,
+			},
+		})
+	}
+	return marshal(doc)
+}
+
+// lastMod форматирует момент изменения в формате W3C Datetime.
+func lastMod(t int64) string {
+	if t == 0 {
+		return ""
+	}
+	return time.Unix(t, 0).UTC().Format(time.RFC3339)
+}
+
+// marshal кодирует документ в XML с объявлением кодировки UTF-8.
+func marshal(doc interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
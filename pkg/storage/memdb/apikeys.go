@@ -0,0 +1,71 @@
+package memdb
+
+import (
+	"GoNews/pkg/storage"
+	"context"
+	"fmt"
+	"sort"
+)
+
+// APIKeys возвращает все ключи API, упорядоченные по ID.
+func (s *Store) APIKeys(ctx context.Context) ([]storage.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]storage.APIKey, 0, len(s.apiKeys))
+	for _, k := range s.apiKeys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+
+	return keys, nil
+}
+
+// APIKeyByHash возвращает ключ API по хешу.
+func (s *Store) APIKeyByHash(ctx context.Context, hash string) (storage.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, k := range s.apiKeys {
+		if k.Hash == hash {
+			return k, nil
+		}
+	}
+
+	return storage.APIKey{}, fmt.Errorf("ключ API: %w", storage.ErrNotFound)
+}
+
+// AddAPIKey сохраняет ключ API, присваивая ему очередной ID.
+func (s *Store) AddAPIKey(ctx context.Context, k storage.APIKey) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.apiKeys {
+		if existing.Hash == k.Hash {
+			return 0, fmt.Errorf("ключ API уже существует: %w", storage.ErrConflict)
+		}
+	}
+
+	k.ID = s.nextKeyID
+	s.nextKeyID++
+	s.apiKeys[k.ID] = k
+
+	return k.ID, nil
+}
+
+// RevokeAPIKey отзывает ключ API.
+func (s *Store) RevokeAPIKey(ctx context.Context, id int, at int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k, ok := s.apiKeys[id]
+	if !ok {
+		return fmt.Errorf("ключ API с ID %d: %w", id, storage.ErrNotFound)
+	}
+	if k.RevokedAt == 0 {
+		k.RevokedAt = at
+		s.apiKeys[id] = k
+	}
+
+	return nil
+}
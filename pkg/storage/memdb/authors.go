@@ -0,0 +1,120 @@
+package memdb
+
+import (
+	"GoNews/pkg/storage"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Authors возвращает всех авторов, упорядоченных по ID.
+func (s *Store) Authors(ctx context.Context) ([]storage.Author, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	authors := make([]storage.Author, 0, len(s.authors))
+	for _, a := range s.authors {
+		authors = append(authors, a)
+	}
+	sort.Slice(authors, func(i, j int) bool { return authors[i].ID < authors[j].ID })
+
+	return authors, nil
+}
+
+// Author возвращает автора по ID.
+func (s *Store) Author(ctx context.Context, id int) (storage.Author, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a, ok := s.authors[id]
+	if !ok {
+		return storage.Author{}, fmt.Errorf("автор с ID %d: %w", id, storage.ErrNotFound)
+	}
+
+	return a, nil
+}
+
+// AddAuthor добавляет нового автора, присваивая ему очередной ID.
+func (s *Store) AddAuthor(ctx context.Context, author storage.Author) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	author.ID = s.nextAuthorID
+	s.nextAuthorID++
+	s.authors[author.ID] = author
+
+	return author.ID, nil
+}
+
+// UpdateAuthor переименовывает автора и обновляет имя в его публикациях.
+// Имя автора входит в публикацию, поэтому её версия и момент изменения
+// тоже обновляются: иначе клиенты сочли бы сохранённую копию актуальной.
+func (s *Store) UpdateAuthor(ctx context.Context, author storage.Author) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.authors[author.ID]
+	if !ok {
+		return fmt.Errorf("автор с ID %d: %w", author.ID, storage.ErrNotFound)
+	}
+	s.authors[author.ID] = author
+	if old.Name == author.Name {
+		return nil
+	}
+
+	now := time.Now().Unix()
+	for id, p := range s.posts {
+		if p.AuthorID == author.ID {
+			p.AuthorName = author.Name
+			p.UpdatedAt = now
+			p.Version++
+			s.posts[id] = p
+		}
+	}
+
+	return nil
+}
+
+// DeleteAuthor удаляет автора. Если у автора есть публикации,
+// они удаляются при cascade, иначе возвращается ErrAuthorHasPosts.
+// Учётные записи пользователей и ключи API автора остаются без связанного автора.
+func (s *Store) DeleteAuthor(ctx context.Context, id int, cascade bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.authors[id]; !ok {
+		return fmt.Errorf("автор с ID %d: %w", id, storage.ErrNotFound)
+	}
+
+	var posts []storage.Post
+	for _, p := range s.posts {
+		if p.AuthorID == id {
+			posts = append(posts, p)
+		}
+	}
+	if len(posts) > 0 && !cascade {
+		return fmt.Errorf("автор с ID %d: %w", id, storage.ErrAuthorHasPosts)
+	}
+
+	for _, p := range posts {
+		s.index.remove(p)
+		delete(s.posts, p.ID)
+		delete(s.revisions, p.ID)
+	}
+	for uid, u := range s.users {
+		if u.AuthorID == id {
+			u.AuthorID = 0
+			s.users[uid] = u
+		}
+	}
+	for kid, k := range s.apiKeys {
+		if k.AuthorID == id {
+			k.AuthorID = 0
+			s.apiKeys[kid] = k
+		}
+	}
+	delete(s.authors, id)
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package memdb
+
+import (
+	"GoNews/pkg/storage"
+	"strings"
+)
+
+// predicate - условие отбора публикации.
+type predicate func(storage.Post) bool
+
+// predicates преобразует фильтр в набор условий отбора.
+func predicates(f storage.PostFilter) []predicate {
+	preds := []predicate{
+		func(p storage.Post) bool { return (p.DeletedAt != 0) == f.Deleted },
+	}
+
+	if f.AuthorID != 0 {
+		preds = append(preds, func(p storage.Post) bool { return p.AuthorID == f.AuthorID })
+	}
+	if f.CreatedAfter != 0 {
+		preds = append(preds, func(p storage.Post) bool { return p.CreatedAt >= f.CreatedAfter })
+	}
+	if f.CreatedBefore != 0 {
+		preds = append(preds, func(p storage.Post) bool { return p.CreatedAt < f.CreatedBefore })
+	}
+	if f.PublishedAfter != 0 {
+		preds = append(preds, func(p storage.Post) bool { return p.PublishedAt >= f.PublishedAfter })
+	}
+	if f.Status != "" {
+		preds = append(preds, func(p storage.Post) bool { return p.Status == f.Status })
+	}
+	if f.TitleContains != "" {
+		substr := strings.ToLower(f.TitleContains)
+		preds = append(preds, func(p storage.Post) bool {
+			return strings.Contains(strings.ToLower(p.Title), substr)
+		})
+	}
+
+	return preds
+}
+
+// match проверяет, что публикация удовлетворяет всем условиям.
+func match(p storage.Post, preds []predicate) bool {
+	for _, pred := range preds {
+		if !pred(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// less возвращает функцию сравнения публикаций для заданной сортировки.
+// При равенстве значений поля публикации упорядочиваются по ID.
+func less(f storage.PostFilter) func(a, b storage.Post) bool {
+	return func(a, b storage.Post) bool {
+		var cmp int
+		switch f.SortBy {
+		case storage.SortByCreatedAt:
+			cmp = compareInt64(a.CreatedAt, b.CreatedAt)
+		case storage.SortByPublishedAt:
+			cmp = compareInt64(a.PublishedAt, b.PublishedAt)
+		case storage.SortByTitle:
+			cmp = strings.Compare(a.Title, b.Title)
+		case storage.SortByDeletedAt:
+			cmp = compareInt64(a.DeletedAt, b.DeletedAt)
+		}
+		if cmp == 0 {
+			cmp = compareInt64(int64(a.ID), int64(b.ID))
+		}
+		if f.SortDesc {
+			return cmp > 0
+		}
+		return cmp < 0
+	}
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -1,37 +1,336 @@
 package memdb
 
-import "GoNews/pkg/storage"
+import (
+	"GoNews/pkg/storage"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
 
 // Хранилище данных.
-type Store struct{}
+// Публикации хранятся в памяти процесса, доступ защищён мьютексом,
+// поэтому хранилище можно безопасно использовать из обработчиков HTTP.
+type Store struct {
+	mu           sync.RWMutex
+	posts        map[int]storage.Post
+	revisions    map[int][]storage.Revision // история изменений публикаций по ID
+	authors      map[int]storage.Author
+	index        invertedIndex // индекс для полнотекстового поиска
+	nextID       int
+	nextAuthorID int
+	locks        map[string]time.Time // захваченные блокировки и сроки их истечения
+	apiKeys      map[int]storage.APIKey
+	nextKeyID    int
+	users        map[int]storage.User
+	nextUserID   int
+}
 
 // Конструктор объекта хранилища.
 func New() *Store {
-	return new(Store)
+	s := &Store{
+		posts:        make(map[int]storage.Post),
+		revisions:    make(map[int][]storage.Revision),
+		authors:      make(map[int]storage.Author),
+		index:        make(invertedIndex),
+		nextID:       1,
+		nextAuthorID: 1,
+		locks:        make(map[string]time.Time),
+		apiKeys:      make(map[int]storage.APIKey),
+		nextKeyID:    1,
+		users:        make(map[int]storage.User),
+		nextUserID:   1,
+	}
+
+	// Заполняем хранилище демонстрационными данными.
+	ctx := context.Background()
+	authorID, _ := s.AddAuthor(ctx, sampleAuthor)
+	for _, p := range samplePosts {
+		p.AuthorID = authorID
+		p.PublishedAt = time.Now().Unix()
+		s.AddPost(ctx, p)
+	}
+
+	return s
 }
 
-func (s *Store) Posts() ([]storage.Post, error) {
+// Posts возвращает страницу публикаций, удовлетворяющих фильтру,
+// и общее количество таких публикаций.
+func (s *Store) Posts(ctx context.Context, f storage.PostFilter, limit, offset int) ([]storage.Post, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	preds := predicates(f)
+	var posts []storage.Post
+	for _, p := range s.posts {
+		if match(p, preds) {
+			posts = append(posts, p)
+		}
+	}
+	cmp := less(f)
+	sort.Slice(posts, func(i, j int) bool { return cmp(posts[i], posts[j]) })
+
+	total := len(posts)
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+
+	return posts[offset:end], total, nil
+}
+
+// PostsAfter возвращает до limit публикаций, удовлетворяющих фильтру
+// и следующих за курсором, в порядке убывания (CreatedAt, ID).
+// Пустой курсор означает начало ленты. Сортировка из фильтра не применяется.
+func (s *Store) PostsAfter(ctx context.Context, f storage.PostFilter, cursor *storage.Cursor, limit int) ([]storage.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	preds := predicates(f)
+	var posts []storage.Post
+	for _, p := range s.posts {
+		if !match(p, preds) {
+			continue
+		}
+		if cursor == nil || p.CreatedAt < cursor.CreatedAt ||
+			(p.CreatedAt == cursor.CreatedAt && p.ID < cursor.ID) {
+			posts = append(posts, p)
+		}
+	}
+	cmp := less(storage.PostFilter{SortBy: storage.SortByCreatedAt, SortDesc: true})
+	sort.Slice(posts, func(i, j int) bool { return cmp(posts[i], posts[j]) })
+
+	if len(posts) > limit {
+		posts = posts[:limit]
+	}
+
 	return posts, nil
 }
 
-func (s *Store) AddPost(storage.Post) error {
+// Post возвращает публикацию по ID.
+func (s *Store) Post(ctx context.Context, id int) (storage.Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.posts[id]
+	if !ok || p.DeletedAt != 0 {
+		return storage.Post{}, fmt.Errorf("публикация с ID %d: %w", id, storage.ErrNotFound)
+	}
+
+	return p, nil
+}
+
+// AddPost добавляет новую публикацию, присваивая ей очередной ID.
+func (s *Store) AddPost(ctx context.Context, post storage.Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	author, ok := s.authors[post.AuthorID]
+	if !ok {
+		return fmt.Errorf("автор с ID %d: %w", post.AuthorID, storage.ErrAuthorNotFound)
+	}
+
+	now := time.Now().Unix()
+	post.AuthorName = author.Name
+	post.ID = s.nextID
+	s.nextID++
+	post.CreatedAt = now
+	post.UpdatedAt = now
+	post.Version = 1
+	if post.Status == "" {
+		post.Status = storage.PublicationStatus(post.PublishedAt, now)
+	}
+
+	s.posts[post.ID] = post
+	s.index.add(post)
+	s.addRevision(ctx, post)
+	return nil
+}
+
+// UpdatePost обновляет непустые поля существующей публикации.
+// Состояние и момент публикации меняются только через PublishPost.
+func (s *Store) UpdatePost(ctx context.Context, post storage.Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[post.ID]
+	if !ok || p.DeletedAt != 0 {
+		return fmt.Errorf("публикация с ID %d: %w", post.ID, storage.ErrNotFound)
+	}
+	old := p
+
+	if post == (storage.Post{ID: post.ID, Version: post.Version}) {
+		return fmt.Errorf("нет полей для обновления: %w", storage.ErrInvalid)
+	}
+	if post.Version != 0 && post.Version != p.Version {
+		return fmt.Errorf("публикация с ID %d: %w", post.ID, storage.ErrStale)
+	}
+
+	if post.Title != "" {
+		p.Title = post.Title
+	}
+	if post.Content != "" {
+		p.Content = post.Content
+	}
+	if post.AuthorID != 0 {
+		author, ok := s.authors[post.AuthorID]
+		if !ok {
+			return fmt.Errorf("автор с ID %d: %w", post.AuthorID, storage.ErrAuthorNotFound)
+		}
+		p.AuthorID = author.ID
+		p.AuthorName = author.Name
+	}
+	if post.CreatedAt != 0 {
+		p.CreatedAt = post.CreatedAt
+	}
+	p.UpdatedAt = time.Now().Unix()
+	p.Version++
+
+	s.index.remove(old)
+	s.posts[p.ID] = p
+	s.index.add(p)
+	s.addRevision(ctx, p)
+	return nil
+}
+
+// DeletePost перемещает публикацию в корзину по ID.
+// Публикация остаётся в индексе: результаты поиска отбираются по фильтру.
+func (s *Store) DeletePost(ctx context.Context, post storage.Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[post.ID]
+	if !ok || p.DeletedAt != 0 {
+		return fmt.Errorf("публикация с ID %d: %w", post.ID, storage.ErrNotFound)
+	}
+	if post.Version != 0 && post.Version != p.Version {
+		return fmt.Errorf("публикация с ID %d: %w", post.ID, storage.ErrStale)
+	}
+
+	p.DeletedAt = time.Now().Unix()
+	p.UpdatedAt = p.DeletedAt
+	p.Version++
+	s.posts[p.ID] = p
 	return nil
 }
-func (s *Store) UpdatePost(storage.Post) error {
+
+// RestorePost восстанавливает публикацию из корзины.
+func (s *Store) RestorePost(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[id]
+	if !ok || p.DeletedAt == 0 {
+		return fmt.Errorf("публикация с ID %d в корзине: %w", id, storage.ErrNotFound)
+	}
+
+	p.DeletedAt = 0
+	p.UpdatedAt = time.Now().Unix()
+	p.Version++
+	s.posts[id] = p
 	return nil
 }
-func (s *Store) DeletePost(storage.Post) error {
+
+// PurgePosts окончательно удаляет публикации, перемещённые в корзину
+// раньше before, и возвращает их количество.
+func (s *Store) PurgePosts(ctx context.Context, before int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int
+	for id, p := range s.posts {
+		if p.DeletedAt != 0 && p.DeletedAt < before {
+			s.index.remove(p)
+			delete(s.posts, id)
+			delete(s.revisions, id)
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// PublishPost публикует публикацию в момент at: если он уже наступил,
+// публикация становится опубликованной, иначе - запланированной.
+func (s *Store) PublishPost(ctx context.Context, id int, at int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[id]
+	if !ok || p.DeletedAt != 0 {
+		return fmt.Errorf("публикация с ID %d: %w", id, storage.ErrNotFound)
+	}
+
+	now := time.Now().Unix()
+	p.PublishedAt = at
+	p.Status = storage.PublicationStatus(at, now)
+	p.UpdatedAt = now
+	p.Version++
+	s.posts[id] = p
+
 	return nil
 }
 
-var posts = []storage.Post{
+// PublishDue публикует запланированные публикации, момент публикации
+// которых не позже now, и возвращает их.
+func (s *Store) PublishDue(ctx context.Context, now int64) ([]storage.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []storage.Post
+	for id, p := range s.posts {
+		if p.Status != storage.StatusScheduled || p.PublishedAt > now || p.DeletedAt != 0 {
+			continue
+		}
+		p.Status = storage.StatusPublished
+		p.UpdatedAt = now
+		p.Version++
+		s.posts[id] = p
+		due = append(due, p)
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].PublishedAt < due[j].PublishedAt })
+
+	return due, nil
+}
+
+// TryLock захватывает блокировку name. Хранилище в памяти доступно только
+// одному процессу, поэтому блокировка действует внутри процесса.
+func (s *Store) TryLock(ctx context.Context, name string, ttl time.Duration) (func(), bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if expires, ok := s.locks[name]; ok && now.Before(expires) {
+		return nil, false, nil
+	}
+	expires := now.Add(ttl)
+	s.locks[name] = expires
+
+	unlock := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		// Блокировку могли перехватить после истечения срока.
+		if s.locks[name] == expires {
+			delete(s.locks, name)
+		}
+	}
+	return unlock, true, nil
+}
+
+// Демонстрационный автор, которым заполняется новое хранилище.
+var sampleAuthor = storage.Author{Name: "The Go Authors"}
+
+// Демонстрационные публикации, которыми заполняется новое хранилище.
+var samplePosts = []storage.Post{
 	{
-		ID:      1,
 		Title:   "Effective Go",
 		Content: "wololo",
 	},
 	{
-		ID:      2,
 		Title:   "The Go Memory Model",
 		Content: "The Go memory model specifies the conditions under which reads of a variable in one goroutine can be guaranteed to observe values produced by writes to the same variable in a different goroutine.",
 	},
@@ -0,0 +1,49 @@
+package memdb
+
+import (
+	"GoNews/pkg/storage"
+	"context"
+	"fmt"
+	"time"
+)
+
+// addRevision сохраняет текущее состояние публикации как очередную ревизию.
+// Вызывается при захваченном мьютексе.
+func (s *Store) addRevision(ctx context.Context, p storage.Post) {
+	revs := s.revisions[p.ID]
+	s.revisions[p.ID] = append(revs, storage.Revision{
+		PostID:     p.ID,
+		Number:     len(revs) + 1,
+		Title:      p.Title,
+		Content:    p.Content,
+		AuthorID:   p.AuthorID,
+		AuthorName: p.AuthorName,
+		Editor:     storage.EditorFrom(ctx),
+		CreatedAt:  time.Now().Unix(),
+	})
+}
+
+// Revisions возвращает все ревизии публикации по возрастанию номера.
+func (s *Store) Revisions(ctx context.Context, postID int) ([]storage.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.posts[postID]; !ok {
+		return nil, fmt.Errorf("публикация с ID %d: %w", postID, storage.ErrNotFound)
+	}
+
+	return append([]storage.Revision{}, s.revisions[postID]...), nil
+}
+
+// Revision возвращает ревизию публикации по номеру.
+func (s *Store) Revision(ctx context.Context, postID, number int) (storage.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revs := s.revisions[postID]
+	if number < 1 || number > len(revs) {
+		return storage.Revision{}, fmt.Errorf("ревизия %d публикации с ID %d: %w", number, postID, storage.ErrNotFound)
+	}
+
+	return revs[number-1], nil
+}
@@ -0,0 +1,92 @@
+package memdb
+
+import (
+	"GoNews/pkg/storage"
+	"context"
+	"math"
+	"sort"
+)
+
+// titleWeight - во сколько раз слово заголовка весомее слова текста.
+const titleWeight = 3
+
+// invertedIndex сопоставляет слову частоты его появления в публикациях.
+type invertedIndex map[string]map[int]int
+
+// add индексирует заголовок и текст публикации.
+func (idx invertedIndex) add(p storage.Post) {
+	for _, t := range storage.Terms(p.Title) {
+		idx.inc(t, p.ID, titleWeight)
+	}
+	for _, t := range storage.Terms(p.Content) {
+		idx.inc(t, p.ID, 1)
+	}
+}
+
+// remove удаляет публикацию из индекса.
+func (idx invertedIndex) remove(p storage.Post) {
+	for _, t := range append(storage.Terms(p.Title), storage.Terms(p.Content)...) {
+		delete(idx[t], p.ID)
+		if len(idx[t]) == 0 {
+			delete(idx, t)
+		}
+	}
+}
+
+func (idx invertedIndex) inc(term string, id, n int) {
+	postings, ok := idx[term]
+	if !ok {
+		postings = make(map[int]int)
+		idx[term] = postings
+	}
+	postings[id] += n
+}
+
+// Search ищет публикации, удовлетворяющие фильтру и содержащие слова запроса, и упорядочивает их
+// по убыванию релевантности (TF-IDF с повышенным весом заголовка).
+func (s *Store) Search(ctx context.Context, f storage.PostFilter, query string, limit, offset int) ([]storage.SearchResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	preds := predicates(f)
+	terms := storage.Terms(query)
+	ranks := make(map[int]float64)
+	for _, t := range terms {
+		postings := s.index[t]
+		if len(postings) == 0 {
+			continue
+		}
+		idf := math.Log(1 + float64(len(s.posts))/float64(len(postings)))
+		for id, tf := range postings {
+			if match(s.posts[id], preds) {
+				ranks[id] += float64(tf) * idf
+			}
+		}
+	}
+
+	results := make([]storage.SearchResult, 0, len(ranks))
+	for id, rank := range ranks {
+		p := s.posts[id]
+		results = append(results, storage.SearchResult{
+			Post:    p,
+			Rank:    rank,
+			Snippet: storage.Snippet(p.Title, p.Content, terms),
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].ID > results[j].ID
+	})
+
+	if offset > len(results) {
+		offset = len(results)
+	}
+	results = results[offset:]
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
@@ -0,0 +1,153 @@
+package memdb
+
+import (
+	"GoNews/pkg/storage"
+	"context"
+	"fmt"
+)
+
+// AddUser создаёт пользователя и связанного с ним автора u.Name.
+func (s *Store) AddUser(ctx context.Context, u storage.User) (storage.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.users {
+		if existing.Email == u.Email {
+			return storage.User{}, fmt.Errorf("пользователь %s уже существует: %w", u.Email, storage.ErrConflict)
+		}
+		if u.OIDCSubject != "" && existing.OIDCIssuer == u.OIDCIssuer && existing.OIDCSubject == u.OIDCSubject {
+			return storage.User{}, fmt.Errorf("учётная запись OIDC уже привязана: %w", storage.ErrConflict)
+		}
+	}
+
+	author := storage.Author{ID: s.nextAuthorID, Name: u.Name}
+	s.nextAuthorID++
+	s.authors[author.ID] = author
+
+	u.ID = s.nextUserID
+	s.nextUserID++
+	u.AuthorID = author.ID
+	s.users[u.ID] = u
+
+	return u, nil
+}
+
+// User возвращает пользователя по ID.
+func (s *Store) User(ctx context.Context, id int) (storage.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return storage.User{}, fmt.Errorf("пользователь с ID %d: %w", id, storage.ErrNotFound)
+	}
+
+	return s.withName(u), nil
+}
+
+// UserByEmail возвращает пользователя по адресу электронной почты.
+func (s *Store) UserByEmail(ctx context.Context, email string) (storage.User, error) {
+	return s.findUser(func(u storage.User) bool { return u.Email == email })
+}
+
+// UserByResetToken возвращает пользователя по хешу токена сброса пароля.
+func (s *Store) UserByResetToken(ctx context.Context, hash string) (storage.User, error) {
+	if hash == "" {
+		return storage.User{}, fmt.Errorf("пользователь: %w", storage.ErrNotFound)
+	}
+	return s.findUser(func(u storage.User) bool { return u.ResetTokenHash == hash })
+}
+
+// UserByOIDC возвращает пользователя, привязанного к учётной записи subject
+// провайдера OpenID Connect issuer.
+func (s *Store) UserByOIDC(ctx context.Context, issuer, subject string) (storage.User, error) {
+	if subject == "" {
+		return storage.User{}, fmt.Errorf("пользователь: %w", storage.ErrNotFound)
+	}
+	return s.findUser(func(u storage.User) bool { return u.OIDCIssuer == issuer && u.OIDCSubject == subject })
+}
+
+// LinkOIDC привязывает к пользователю учётную запись провайдера OpenID Connect.
+func (s *Store) LinkOIDC(ctx context.Context, id int, issuer, subject string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for uid, u := range s.users {
+		if uid != id && u.OIDCIssuer == issuer && u.OIDCSubject == subject {
+			return fmt.Errorf("учётная запись OIDC уже привязана: %w", storage.ErrConflict)
+		}
+	}
+	u, ok := s.users[id]
+	if !ok {
+		return fmt.Errorf("пользователь с ID %d: %w", id, storage.ErrNotFound)
+	}
+	u.OIDCIssuer, u.OIDCSubject = issuer, subject
+	s.users[id] = u
+
+	return nil
+}
+
+// findUser возвращает первого пользователя, удовлетворяющего условию.
+func (s *Store) findUser(match func(storage.User) bool) (storage.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if match(u) {
+			return s.withName(u), nil
+		}
+	}
+
+	return storage.User{}, fmt.Errorf("пользователь: %w", storage.ErrNotFound)
+}
+
+// withName возвращает пользователя с текущим именем связанного автора,
+// как в PostgreSQL и MongoDB: имя меняется вместе с автором,
+// а у пользователя без автора оно пустое.
+// Вызывается под блокировкой s.mu.
+func (s *Store) withName(u storage.User) storage.User {
+	u.Name = s.authors[u.AuthorID].Name
+	return u
+}
+
+// SetPassword меняет хеш пароля пользователя, аннулирует токен сброса
+// и увеличивает версию токенов пользователя.
+func (s *Store) SetPassword(ctx context.Context, id int, hash string) error {
+	return s.updateUser(id, func(u *storage.User) {
+		u.PasswordHash = hash
+		u.ResetTokenHash = ""
+		u.ResetExpiresAt = 0
+		u.TokenVersion++
+	})
+}
+
+// SetResetToken сохраняет хеш токена сброса пароля и момент его истечения.
+func (s *Store) SetResetToken(ctx context.Context, id int, hash string, expiresAt int64) error {
+	return s.updateUser(id, func(u *storage.User) {
+		u.ResetTokenHash = hash
+		u.ResetExpiresAt = expiresAt
+	})
+}
+
+// SetUserRole меняет роль пользователя и увеличивает версию его токенов.
+func (s *Store) SetUserRole(ctx context.Context, id int, role string) error {
+	return s.updateUser(id, func(u *storage.User) {
+		u.Role = role
+		u.TokenVersion++
+	})
+}
+
+// updateUser применяет изменение к пользователю с указанным ID.
+func (s *Store) updateUser(id int, change func(*storage.User)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return fmt.Errorf("пользователь с ID %d: %w", id, storage.ErrNotFound)
+	}
+	change(&u)
+	s.users[id] = u
+
+	return nil
+}
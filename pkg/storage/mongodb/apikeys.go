@@ -0,0 +1,125 @@
+package mongodb
+
+import (
+	"GoNews/pkg/storage"
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// apiKeyDocument описывает представление ключа API в коллекции MongoDB.
+type apiKeyDocument struct {
+	ID        int      `bson:"id"`
+	Name      string   `bson:"name"`
+	Hash      string   `bson:"hash"`
+	Scopes    []string `bson:"scopes"`
+	Role      string   `bson:"role"`
+	AuthorID  int      `bson:"author_id"`
+	CreatedAt int64    `bson:"created_at"`
+	RevokedAt int64    `bson:"revoked_at"`
+}
+
+// toAPIKey преобразует документ в ключ API.
+func (d apiKeyDocument) toAPIKey() storage.APIKey {
+	return storage.APIKey{
+		ID:        d.ID,
+		Name:      d.Name,
+		Hash:      d.Hash,
+		Scopes:    d.Scopes,
+		Role:      d.Role,
+		AuthorID:  d.AuthorID,
+		CreatedAt: d.CreatedAt,
+		RevokedAt: d.RevokedAt,
+	}
+}
+
+// APIKeys возвращает все ключи API, упорядоченные по ID.
+func (s *Store) APIKeys(ctx context.Context) ([]storage.APIKey, error) {
+	var keys []storage.APIKey
+
+	opts := options.Find().SetSort(bson.D{{Key: "id", Value: 1}})
+	cursor, err := s.apiKeys.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc apiKeyDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("ошибка чтения строки: %w", err)
+		}
+		keys = append(keys, doc.toAPIKey())
+	}
+
+	return keys, cursor.Err()
+}
+
+// APIKeyByHash возвращает ключ API по хешу.
+func (s *Store) APIKeyByHash(ctx context.Context, hash string) (storage.APIKey, error) {
+	var doc apiKeyDocument
+	err := s.apiKeys.FindOne(ctx, bson.M{"hash": hash}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return storage.APIKey{}, fmt.Errorf("ключ API: %w", storage.ErrNotFound)
+	}
+	if err != nil {
+		return storage.APIKey{}, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+
+	return doc.toAPIKey(), nil
+}
+
+// AddAPIKey сохраняет ключ API и возвращает его ID.
+func (s *Store) AddAPIKey(ctx context.Context, k storage.APIKey) (int, error) {
+	nextID, err := getNextSequence(ctx, s.counters, "apiKeyID")
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = s.apiKeys.InsertOne(ctx, apiKeyDocument{
+		ID:        nextID,
+		Name:      k.Name,
+		Hash:      k.Hash,
+		Scopes:    k.Scopes,
+		Role:      k.Role,
+		AuthorID:  k.AuthorID,
+		CreatedAt: k.CreatedAt,
+		RevokedAt: k.RevokedAt,
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return 0, fmt.Errorf("ключ API уже существует: %w", storage.ErrConflict)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при добавлении ключа API: %w", err)
+	}
+
+	return nextID, nil
+}
+
+// RevokeAPIKey отзывает ключ API.
+func (s *Store) RevokeAPIKey(ctx context.Context, id int, at int64) error {
+	res, err := s.apiKeys.UpdateOne(ctx,
+		bson.M{"id": id, "revoked_at": 0},
+		bson.M{"$set": bson.M{"revoked_at": at}})
+	if err != nil {
+		return fmt.Errorf("ошибка при отзыве ключа API: %w", err)
+	}
+	if res.MatchedCount > 0 {
+		return nil
+	}
+
+	// Ключ не найден среди действующих: он уже отозван или не существует.
+	n, err := s.apiKeys.CountDocuments(ctx, bson.M{"id": id})
+	if err != nil {
+		return fmt.Errorf("ошибка при проверке ключа API: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("ключ API с ID %d: %w", id, storage.ErrNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package mongodb
+
+import (
+	"GoNews/pkg/storage"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// authorDocument описывает представление автора в коллекции MongoDB.
+type authorDocument struct {
+	ID   int    `bson:"id"`
+	Name string `bson:"name"`
+}
+
+// Authors возвращает всех авторов, упорядоченных по ID.
+func (s *Store) Authors(ctx context.Context) ([]storage.Author, error) {
+	var authors []storage.Author
+
+	opts := options.Find().SetSort(bson.D{{Key: "id", Value: 1}})
+	cursor, err := s.authors.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc authorDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("ошибка чтения строки: %w", err)
+		}
+		authors = append(authors, storage.Author{ID: doc.ID, Name: doc.Name})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+
+	return authors, nil
+}
+
+// Author возвращает автора по ID.
+func (s *Store) Author(ctx context.Context, id int) (storage.Author, error) {
+	var doc authorDocument
+	err := s.authors.FindOne(ctx, bson.M{"id": id}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return storage.Author{}, fmt.Errorf("автор с ID %d: %w", id, storage.ErrNotFound)
+	}
+	if err != nil {
+		return storage.Author{}, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+
+	return storage.Author{ID: doc.ID, Name: doc.Name}, nil
+}
+
+// AddAuthor добавляет нового автора и возвращает его ID.
+func (s *Store) AddAuthor(ctx context.Context, author storage.Author) (int, error) {
+	nextID, err := getNextSequence(ctx, s.counters, "authorID")
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = s.authors.InsertOne(ctx, authorDocument{ID: nextID, Name: author.Name})
+	if mongo.IsDuplicateKeyError(err) {
+		return 0, fmt.Errorf("автор с ID %d уже существует: %w", nextID, storage.ErrConflict)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при добавлении автора: %w", err)
+	}
+
+	return nextID, nil
+}
+
+// UpdateAuthor переименовывает автора и обновляет имя в его публикациях.
+// Имя автора входит в публикацию, поэтому её версия и момент изменения
+// тоже обновляются: иначе клиенты сочли бы сохранённую копию актуальной.
+func (s *Store) UpdateAuthor(ctx context.Context, author storage.Author) error {
+	var old authorDocument
+	err := s.authors.FindOneAndUpdate(ctx,
+		bson.M{"id": author.ID},
+		bson.M{"$set": bson.M{"name": author.Name}}).Decode(&old)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("автор с ID %d: %w", author.ID, storage.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении автора: %w", err)
+	}
+	if old.Name == author.Name {
+		return nil
+	}
+
+	_, err = s.Collection.UpdateMany(ctx,
+		bson.M{"author_id": author.ID},
+		bson.M{
+			"$set": bson.M{"author_name": author.Name, "updated_at": time.Now().Unix()},
+			"$inc": bson.M{"version": 1},
+		})
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении имени автора в публикациях: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAuthor удаляет автора. Если у автора есть публикации,
+// они удаляются при cascade, иначе возвращается ErrAuthorHasPosts.
+// Учётные записи пользователей и ключи API автора остаются без связанного автора.
+func (s *Store) DeleteAuthor(ctx context.Context, id int, cascade bool) error {
+	if _, err := s.Author(ctx, id); err != nil {
+		return err
+	}
+
+	if cascade {
+		if _, err := s.deletePosts(ctx, bson.M{"author_id": id}); err != nil {
+			return fmt.Errorf("ошибка при удалении публикаций автора: %w", err)
+		}
+	} else {
+		n, err := s.Collection.CountDocuments(ctx, bson.M{"author_id": id}, options.Count().SetLimit(1))
+		if err != nil {
+			return fmt.Errorf("ошибка при проверке публикаций автора: %w", err)
+		}
+		if n > 0 {
+			return fmt.Errorf("автор с ID %d: %w", id, storage.ErrAuthorHasPosts)
+		}
+	}
+
+	res, err := s.authors.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении автора: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return fmt.Errorf("автор с ID %d: %w", id, storage.ErrNotFound)
+	}
+
+	_, err = s.users.UpdateMany(ctx, bson.M{"author_id": id}, bson.M{"$set": bson.M{"author_id": 0}})
+	if err != nil {
+		return fmt.Errorf("ошибка при отвязке пользователей от автора: %w", err)
+	}
+	_, err = s.apiKeys.UpdateMany(ctx, bson.M{"author_id": id}, bson.M{"$set": bson.M{"author_id": 0}})
+	if err != nil {
+		return fmt.Errorf("ошибка при отвязке ключей API от автора: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package mongodb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TryLock захватывает блокировку name, арендуя документ в коллекции locks
+// на время ttl. Документ хранит случайный идентификатор владельца аренды
+// и момент её истечения. Если экземпляр сервера остановился, не освободив блокировку,
+// её захватит другой экземпляр по истечении аренды.
+func (s *Store) TryLock(ctx context.Context, name string, ttl time.Duration) (func(), bool, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, false, fmt.Errorf("не удалось создать идентификатор владельца: %w", err)
+	}
+	owner := hex.EncodeToString(buf)
+
+	now := time.Now()
+	_, err := s.locks.UpdateOne(ctx,
+		bson.M{"_id": name, "expires_at": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"owner": owner, "expires_at": now.Add(ttl)}},
+		options.Update().SetUpsert(true))
+	// Действующая аренда не подходит под фильтр, и вставка нового
+	// документа с тем же _id завершается ошибкой дубликата ключа.
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("не удалось захватить блокировку %s: %w", name, err)
+	}
+
+	unlock := func() {
+		s.locks.DeleteOne(context.Background(), bson.M{"_id": name, "owner": owner})
+	}
+	return unlock, true, nil
+}
@@ -0,0 +1,466 @@
+package mongodb
+
+import (
+	"GoNews/pkg/storage"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrationTimeout - предельное время выполнения всех миграций.
+const migrationTimeout = 5 * time.Minute
+
+// migration - версия индексов и схемы коллекций.
+// Функции up должны быть идемпотентными: создание индексов и collMod
+// можно безопасно повторить, если два экземпляра сервера запущены
+// одновременно или предыдущий запуск прервался до записи о миграции.
+type migration struct {
+	version int
+	name    string
+	up      func(ctx context.Context, s *Store) error
+}
+
+// migrations - список миграций по возрастанию версий.
+// Новые миграции добавляются только в конец списка.
+var migrations = []migration{
+	{1, "unique_ids", migrateUniqueIDs},
+	{2, "posts_query_indexes", migratePostsQueryIndexes},
+	{3, "posts_text_index", migratePostsTextIndex},
+	{4, "posts_validator", migratePostsValidator},
+	{5, "posts_status", migratePostsStatus},
+	{6, "posts_soft_delete", migratePostsSoftDelete},
+	{7, "post_revisions", migratePostRevisions},
+	{8, "posts_version", migratePostsVersion},
+	{9, "posts_updated_at", migratePostsUpdatedAt},
+	{10, "api_keys", migrateAPIKeys},
+	{11, "users", migrateUsers},
+	{12, "api_key_roles", migrateAPIKeyRoles},
+	{13, "user_oidc_identity", migrateUserOIDCIdentity},
+	{14, "authors_backfill", migrateAuthorsBackfill},
+}
+
+// migrationDocument - запись о применённой миграции в коллекции migrations.
+type migrationDocument struct {
+	Version   int    `bson:"_id"`
+	Name      string `bson:"name"`
+	AppliedAt int64  `bson:"applied_at"`
+}
+
+// Migrate применяет все ещё не применённые миграции по возрастанию версий
+// и отмечает их в коллекции migrations.
+func (s *Store) Migrate() error {
+	ctx, cancel := context.WithTimeout(context.Background(), migrationTimeout)
+	defer cancel()
+
+	applied := make(map[int]bool)
+	cursor, err := s.migrations.Find(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("ошибка чтения коллекции migrations: %w", err)
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var doc migrationDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return fmt.Errorf("ошибка чтения строки: %w", err)
+		}
+		applied[doc.Version] = true
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("ошибка чтения коллекции migrations: %w", err)
+	}
+
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+
+		if err := m.up(ctx, s); err != nil {
+			return fmt.Errorf("ошибка выполнения миграции %04d_%s: %w", m.version, m.name, err)
+		}
+
+		_, err := s.migrations.InsertOne(ctx, migrationDocument{Version: m.version, Name: m.name, AppliedAt: time.Now().Unix()})
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("ошибка записи миграции %04d_%s: %w", m.version, m.name, err)
+		}
+		fmt.Printf("Применена миграция %04d_%s.\n", m.version, m.name)
+	}
+
+	fmt.Println("Миграция выполнена успешно!")
+	return nil
+}
+
+// migrateUniqueIDs создаёт уникальные индексы по числовым ID публикаций и авторов.
+func migrateUniqueIDs(ctx context.Context, s *Store) error {
+	for _, c := range []*mongo.Collection{s.Collection, s.authors} {
+		_, err := c.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetName("id_unique").SetUnique(true),
+		})
+		if err != nil {
+			return fmt.Errorf("индекс id в %s: %w", c.Name(), err)
+		}
+	}
+	return nil
+}
+
+// migratePostsQueryIndexes создаёт индексы для отбора публикаций по автору
+// и для ленты, упорядоченной по дате создания.
+func migratePostsQueryIndexes(ctx context.Context, s *Store) error {
+	_, err := s.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "author_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("author_id_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: -1}, {Key: "id", Value: -1}},
+			Options: options.Index().SetName("created_at_id"),
+		},
+	})
+	return err
+}
+
+// migratePostsTextIndex создаёт текстовый индекс для полнотекстового поиска
+// по заголовку и содержанию.
+func migratePostsTextIndex(ctx context.Context, s *Store) error {
+	_, err := s.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "title", Value: "text"}, {Key: "content", Value: "text"}},
+		Options: options.Index().
+			SetName("posts_text").
+			SetDefaultLanguage("russian").
+			SetWeights(bson.D{{Key: "title", Value: 3}, {Key: "content", Value: 1}}),
+	})
+	return err
+}
+
+// statusProperty - JSON-схема поля состояния публикации.
+var statusProperty = bson.M{"enum": bson.A{storage.StatusDraft, storage.StatusScheduled, storage.StatusPublished}}
+
+// postProperties возвращает JSON-схему полей документа публикации.
+func postProperties() bson.M {
+	integer := bson.M{"bsonType": bson.A{"int", "long"}}
+	return bson.M{
+		"id":           integer,
+		"title":        bson.M{"bsonType": "string", "minLength": 1},
+		"content":      bson.M{"bsonType": "string", "minLength": 1},
+		"author_id":    integer,
+		"author_name":  bson.M{"bsonType": "string"},
+		"created_at":   integer,
+		"published_at": integer,
+	}
+}
+
+// migratePostsValidator задаёт JSON-схему документов коллекции публикаций.
+func migratePostsValidator(ctx context.Context, s *Store) error {
+	validator := bson.M{"$jsonSchema": bson.M{
+		"bsonType":   "object",
+		"required":   bson.A{"id", "title", "content", "author_id", "created_at"},
+		"properties": postProperties(),
+	}}
+
+	return setValidator(ctx, s.Collection, validator)
+}
+
+// migratePostsStatus заполняет состояние существующих публикаций по моменту
+// публикации, создаёт индекс для отбора по состоянию и добавляет поле status
+// в JSON-схему.
+func migratePostsStatus(ctx context.Context, s *Store) error {
+	now := time.Now().Unix()
+	missing := bson.M{"status": bson.M{"$exists": false}}
+	backfill := []struct {
+		filter bson.M
+		status string
+	}{
+		{bson.M{"published_at": bson.M{"$in": bson.A{0, nil}}}, storage.StatusDraft},
+		{bson.M{"published_at": bson.M{"$gt": now}}, storage.StatusScheduled},
+		{bson.M{"published_at": bson.M{"$gt": 0, "$lte": now}}, storage.StatusPublished},
+	}
+	for _, b := range backfill {
+		filter := bson.M{"$and": bson.A{missing, b.filter}}
+		if _, err := s.Collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"status": b.status}}); err != nil {
+			return err
+		}
+	}
+
+	_, err := s.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "status", Value: 1}, {Key: "published_at", Value: 1}},
+		Options: options.Index().SetName("status_published_at"),
+	})
+	if err != nil {
+		return err
+	}
+
+	properties := postProperties()
+	properties["status"] = statusProperty
+	validator := bson.M{"$jsonSchema": bson.M{
+		"bsonType":   "object",
+		"required":   bson.A{"id", "title", "content", "author_id", "status", "created_at"},
+		"properties": properties,
+	}}
+
+	return setValidator(ctx, s.Collection, validator)
+}
+
+// migratePostsSoftDelete заполняет отметку удаления существующих публикаций,
+// создаёт индекс для выборки корзины и добавляет поле deleted_at в JSON-схему.
+func migratePostsSoftDelete(ctx context.Context, s *Store) error {
+	_, err := s.Collection.UpdateMany(ctx,
+		bson.M{"deleted_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"deleted_at": 0}})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "deleted_at", Value: 1}},
+		Options: options.Index().
+			SetName("deleted_at").
+			SetPartialFilterExpression(bson.M{"deleted_at": bson.M{"$gt": 0}}),
+	})
+	if err != nil {
+		return err
+	}
+
+	properties := postProperties()
+	properties["status"] = statusProperty
+	properties["deleted_at"] = bson.M{"bsonType": bson.A{"int", "long"}}
+	validator := bson.M{"$jsonSchema": bson.M{
+		"bsonType":   "object",
+		"required":   bson.A{"id", "title", "content", "author_id", "status", "created_at", "deleted_at"},
+		"properties": properties,
+	}}
+
+	return setValidator(ctx, s.Collection, validator)
+}
+
+// migratePostRevisions создаёт уникальный индекс ревизий и сохраняет
+// текущее состояние существующих публикаций как их первую ревизию.
+func migratePostRevisions(ctx context.Context, s *Store) error {
+	_, err := s.revisions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "post_id", Value: 1}, {Key: "number", Value: 1}},
+		Options: options.Index().SetName("post_id_number").SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	cursor, err := s.Collection.Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var p postDocument
+		if err := cursor.Decode(&p); err != nil {
+			return err
+		}
+		rev := revisionDocument{
+			PostID:     p.ID,
+			Number:     1,
+			Title:      p.Title,
+			Content:    p.Content,
+			AuthorID:   p.AuthorID,
+			AuthorName: p.AuthorName,
+			CreatedAt:  p.CreatedAt,
+		}
+		_, err := s.revisions.UpdateOne(ctx,
+			bson.M{"post_id": p.ID, "number": 1},
+			bson.M{"$setOnInsert": rev},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// migratePostsVersion заполняет номер версии существующих публикаций
+// и добавляет поле version в JSON-схему.
+func migratePostsVersion(ctx context.Context, s *Store) error {
+	_, err := s.Collection.UpdateMany(ctx,
+		bson.M{"version": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"version": 1}})
+	if err != nil {
+		return err
+	}
+
+	integer := bson.M{"bsonType": bson.A{"int", "long"}}
+	properties := postProperties()
+	properties["status"] = statusProperty
+	properties["deleted_at"] = integer
+	properties["version"] = bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 1}
+	validator := bson.M{"$jsonSchema": bson.M{
+		"bsonType":   "object",
+		"required":   bson.A{"id", "title", "content", "author_id", "status", "created_at", "deleted_at", "version"},
+		"properties": properties,
+	}}
+
+	return setValidator(ctx, s.Collection, validator)
+}
+
+// migratePostsUpdatedAt заполняет момент последнего изменения существующих
+// публикаций наибольшим из известных моментов создания, публикации и удаления.
+func migratePostsUpdatedAt(ctx context.Context, s *Store) error {
+	_, err := s.Collection.UpdateMany(ctx,
+		bson.M{"updated_at": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"updated_at": bson.M{"$max": bson.A{"$created_at", "$published_at", "$deleted_at"}},
+		}}}})
+	return err
+}
+
+// migrateAPIKeys создаёт уникальные индексы ключей API по ID и хешу.
+func migrateAPIKeys(ctx context.Context, s *Store) error {
+	_, err := s.apiKeys.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetName("id_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "hash", Value: 1}},
+			Options: options.Index().SetName("hash_unique").SetUnique(true),
+		},
+	})
+	return err
+}
+
+// migrateUsers создаёт уникальные индексы пользователей по ID, адресу
+// и хешу токена сброса пароля. Пустой хеш означает отсутствие токена
+// и в уникальный индекс не попадает.
+func migrateUsers(ctx context.Context, s *Store) error {
+	_, err := s.users.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetName("id_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetName("email_unique").SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "reset_token_hash", Value: 1}},
+			Options: options.Index().SetName("reset_token_hash_unique").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"reset_token_hash": bson.M{"$gt": ""}}),
+		},
+	})
+	return err
+}
+
+// migrateAPIKeyRoles назначает роли существующим ключам API. Раньше ключ
+// с разрешением write действовал с ролью editor; теперь роль задаётся
+// при выпуске, а прежние ключи получают наименьшие права: роль admin
+// остаётся только у ключей с разрешением admin.
+func migrateAPIKeyRoles(ctx context.Context, s *Store) error {
+	_, err := s.apiKeys.UpdateMany(ctx,
+		bson.M{"role": bson.M{"$exists": false}, "scopes": "admin"},
+		bson.M{"$set": bson.M{"role": "admin", "author_id": 0}})
+	if err != nil {
+		return err
+	}
+	_, err = s.apiKeys.UpdateMany(ctx,
+		bson.M{"role": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"role": "author", "author_id": 0}})
+	return err
+}
+
+// migrateUserOIDCIdentity создаёт уникальный индекс учётных записей
+// провайдера OpenID Connect, привязанных к пользователям. Пользователи
+// без привязки в индекс не попадают.
+func migrateUserOIDCIdentity(ctx context.Context, s *Store) error {
+	_, err := s.users.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "oidc_issuer", Value: 1}, {Key: "oidc_subject", Value: 1}},
+		Options: options.Index().SetName("oidc_identity_unique").SetUnique(true).
+			SetPartialFilterExpression(bson.M{"oidc_subject": bson.M{"$gt": ""}}),
+	})
+	return err
+}
+
+// migrateAuthorsBackfill создаёт авторов, на которых ссылаются публикации,
+// но которых нет в коллекции authors: публикации, созданные до её появления,
+// хранят только ID и имя автора. Имя автора берётся из его публикаций,
+// а если оно там не задано - "Автор N"; пустые имена в публикациях
+// заполняются именем автора. Счётчик ID авторов сдвигается за наибольший
+// найденный ID, чтобы новые авторы не получили ID восстановленных.
+func migrateAuthorsBackfill(ctx context.Context, s *Store) error {
+	cursor, err := s.Collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$author_id", "name": bson.M{"$max": "$author_name"}}}},
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	maxID := -1
+	for cursor.Next(ctx) {
+		var group struct {
+			AuthorID int    `bson:"_id"`
+			Name     string `bson:"name"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return err
+		}
+		if group.AuthorID > maxID {
+			maxID = group.AuthorID
+		}
+
+		name := group.Name
+		if name == "" {
+			name = fmt.Sprintf("Автор %d", group.AuthorID)
+		}
+		var author authorDocument
+		err := s.authors.FindOneAndUpdate(ctx,
+			bson.M{"id": group.AuthorID},
+			bson.M{"$setOnInsert": authorDocument{ID: group.AuthorID, Name: name}},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&author)
+		if err != nil {
+			return err
+		}
+		_, err = s.Collection.UpdateMany(ctx,
+			bson.M{"author_id": group.AuthorID, "author_name": bson.M{"$in": bson.A{"", nil}}},
+			bson.M{"$set": bson.M{"author_name": author.Name}})
+		if err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if maxID < 0 {
+		return nil
+	}
+
+	// getNextSequence выдаёт текущее значение счётчика, поэтому следующий
+	// ID автора должен быть больше наибольшего из восстановленных.
+	_, err = s.counters.UpdateOne(ctx,
+		bson.M{"_id": "authorID"},
+		bson.M{"$max": bson.M{"seq": maxID + 1}},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+// setValidator устанавливает валидатор коллекции, создавая её при необходимости.
+func setValidator(ctx context.Context, c *mongo.Collection, validator bson.M) error {
+	err := c.Database().RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: c.Name()},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: "moderate"},
+		{Key: "validationAction", Value: "error"},
+	}).Err()
+
+	// Коллекция ещё не создана - создаём её сразу с валидатором
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Name == "NamespaceNotFound" {
+		return c.Database().CreateCollection(ctx, c.Name(), options.CreateCollection().
+			SetValidator(validator).
+			SetValidationLevel("moderate").
+			SetValidationAction("error"))
+	}
+
+	return err
+}
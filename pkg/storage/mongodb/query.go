@@ -0,0 +1,67 @@
+package mongodb
+
+import (
+	"GoNews/pkg/storage"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// sortFields сопоставляет поля сортировки полям документа публикации.
+var sortFields = map[string]string{
+	storage.SortByID:          "id",
+	storage.SortByCreatedAt:   "created_at",
+	storage.SortByPublishedAt: "published_at",
+	storage.SortByTitle:       "title",
+	storage.SortByDeletedAt:   "deleted_at",
+}
+
+// filterDocument преобразует фильтр в условия запроса MongoDB.
+func filterDocument(f storage.PostFilter) bson.M {
+	filter := bson.M{"deleted_at": 0}
+	if f.Deleted {
+		filter["deleted_at"] = bson.M{"$gt": 0}
+	}
+
+	if f.AuthorID != 0 {
+		filter["author_id"] = f.AuthorID
+	}
+	if f.CreatedAfter != 0 || f.CreatedBefore != 0 {
+		created := bson.M{}
+		if f.CreatedAfter != 0 {
+			created["$gte"] = f.CreatedAfter
+		}
+		if f.CreatedBefore != 0 {
+			created["$lt"] = f.CreatedBefore
+		}
+		filter["created_at"] = created
+	}
+	if f.PublishedAfter != 0 {
+		filter["published_at"] = bson.M{"$gte": f.PublishedAfter}
+	}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	if f.TitleContains != "" {
+		filter["title"] = bson.M{"$regex": regexp.QuoteMeta(f.TitleContains), "$options": "i"}
+	}
+
+	return filter
+}
+
+// sortDocument строит порядок сортировки по фильтру.
+// При равенстве значений поля публикации упорядочиваются по ID.
+func sortDocument(f storage.PostFilter) bson.D {
+	field, ok := sortFields[f.SortBy]
+	if !ok {
+		field = "id"
+	}
+	dir := 1
+	if f.SortDesc {
+		dir = -1
+	}
+	if field == "id" {
+		return bson.D{{Key: "id", Value: dir}}
+	}
+	return bson.D{{Key: field, Value: dir}, {Key: "id", Value: dir}}
+}
@@ -3,6 +3,7 @@ package mongodb
 import (
 	"GoNews/pkg/storage"
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -15,7 +16,45 @@ import (
 type Store struct {
 	client     *mongo.Client
 	Collection *mongo.Collection
+	authors    *mongo.Collection
 	counters   *mongo.Collection
+	migrations *mongo.Collection
+	locks      *mongo.Collection
+	revisions  *mongo.Collection
+	apiKeys    *mongo.Collection
+	users      *mongo.Collection
+}
+
+// postDocument описывает представление публикации в коллекции MongoDB.
+type postDocument struct {
+	ID          int    `bson:"id"`
+	Title       string `bson:"title"`
+	Content     string `bson:"content"`
+	AuthorID    int    `bson:"author_id"`
+	AuthorName  string `bson:"author_name"`
+	Status      string `bson:"status"`
+	CreatedAt   int64  `bson:"created_at"`
+	PublishedAt int64  `bson:"published_at"`
+	UpdatedAt   int64  `bson:"updated_at"`
+	DeletedAt   int64  `bson:"deleted_at"`
+	Version     int    `bson:"version"`
+}
+
+// toPost преобразует документ в публикацию.
+func (d postDocument) toPost() storage.Post {
+	return storage.Post{
+		ID:          d.ID,
+		Title:       d.Title,
+		Content:     d.Content,
+		AuthorID:    d.AuthorID,
+		AuthorName:  d.AuthorName,
+		Status:      d.Status,
+		CreatedAt:   d.CreatedAt,
+		PublishedAt: d.PublishedAt,
+		UpdatedAt:   d.UpdatedAt,
+		DeletedAt:   d.DeletedAt,
+		Version:     d.Version,
+	}
 }
 
 type Counter struct {
@@ -23,13 +62,13 @@ type Counter struct {
 	Seq int    `bson:"seq"` // Значение счетчика
 }
 
-func getNextSequence(counterCollection *mongo.Collection, sequenceName string) (int, error) {
+func getNextSequence(ctx context.Context, counterCollection *mongo.Collection, sequenceName string) (int, error) {
 	filter := bson.M{"_id": sequenceName}
 	update := bson.M{"$inc": bson.M{"seq": 1}}
 	var counter Counter
 
 	// Здесь используем counterCollection для выполнения операции FindOneAndUpdate
-	err := counterCollection.FindOneAndUpdate(context.Background(), filter, update).Decode(&counter)
+	err := counterCollection.FindOneAndUpdate(ctx, filter, update).Decode(&counter)
 	if err != nil {
 		return 0, fmt.Errorf("ошибка при получении следующего последовательного номера: %w", err)
 	}
@@ -56,20 +95,32 @@ func New(uri, dbName, collectionName string) (*Store, error) {
 	// Проверка и создание коллекции счетчиков
 	counterCollection := client.Database(dbName).Collection("counters")
 
-	// Проверяем, существует ли счетчик для постов, если нет - создаем
-	if _, err = counterCollection.UpdateOne(
-		context.Background(),
-		bson.M{"_id": "postID"},
-		bson.M{"$setOnInsert": bson.M{"seq": 0}}, // Устанавливаем начальное значение для seq, если счетчик не существует
-		options.Update().SetUpsert(true),
-	); err != nil {
-		return nil, fmt.Errorf("ошибка проверки или создания счетчика постов: %w", err)
+	// Проверяем, существуют ли счетчики для постов и авторов, если нет - создаем
+	for _, name := range []string{"postID", "authorID", "apiKeyID", "userID"} {
+		if _, err = counterCollection.UpdateOne(
+			context.Background(),
+			bson.M{"_id": name},
+			bson.M{"$setOnInsert": bson.M{"seq": 0}}, // Устанавливаем начальное значение для seq, если счетчик не существует
+			options.Update().SetUpsert(true),
+		); err != nil {
+			return nil, fmt.Errorf("ошибка проверки или создания счетчика %s: %w", name, err)
+		}
 	}
 
 	// Создаем основную коллекцию
 	collection := client.Database(dbName).Collection(collectionName)
 
-	return &Store{client: client, Collection: collection, counters: counterCollection}, nil
+	return &Store{
+		client:     client,
+		Collection: collection,
+		authors:    client.Database(dbName).Collection("authors"),
+		counters:   counterCollection,
+		migrations: client.Database(dbName).Collection("migrations"),
+		locks:      client.Database(dbName).Collection("locks"),
+		revisions:  client.Database(dbName).Collection("post_revisions"),
+		apiKeys:    client.Database(dbName).Collection("api_keys"),
+		users:      client.Database(dbName).Collection("users"),
+	}, nil
 }
 
 func (s *Store) Close() {
@@ -82,107 +133,330 @@ func (s *Store) Close() {
 	}
 }
 
-// Posts возвращает все публикации из базы данных.
-func (s *Store) Posts() ([]storage.Post, error) {
+// Posts возвращает страницу публикаций, удовлетворяющих фильтру,
+// и общее количество таких публикаций.
+func (s *Store) Posts(ctx context.Context, f storage.PostFilter, limit, offset int) ([]storage.Post, int, error) {
+	var posts []storage.Post
+
+	filter := filterDocument(f)
+	total, err := s.Collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка подсчёта публикаций: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(sortDocument(f)).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := s.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var post postDocument
+		if err := cursor.Decode(&post); err != nil {
+			return nil, 0, fmt.Errorf("ошибка чтения строки: %w", err)
+		}
+
+		posts = append(posts, post.toPost())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, 0, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+
+	return posts, int(total), nil
+}
+
+// PostsAfter возвращает до limit публикаций, удовлетворяющих фильтру
+// и следующих за курсором, в порядке убывания (created_at, id).
+// Сортировка из фильтра не применяется.
+func (s *Store) PostsAfter(ctx context.Context, f storage.PostFilter, cursor *storage.Cursor, limit int) ([]storage.Post, error) {
 	var posts []storage.Post
 
-	cursor, err := s.Collection.Find(context.Background(), bson.M{})
+	filter := filterDocument(f)
+	if cursor != nil {
+		after := bson.M{"$or": bson.A{
+			bson.M{"created_at": bson.M{"$lt": cursor.CreatedAt}},
+			bson.M{"created_at": cursor.CreatedAt, "id": bson.M{"$lt": cursor.ID}},
+		}}
+		filter = bson.M{"$and": bson.A{filter, after}}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "id", Value: -1}}).
+		SetLimit(int64(limit))
+	cur, err := s.Collection.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
 	}
-	defer cursor.Close(context.Background())
-
-	for cursor.Next(context.Background()) {
-		var post struct {
-			ID          int    `bson:"id"`
-			Title       string `bson:"title"`
-			Content     string `bson:"content"`
-			AuthorID    int    `bson:"author_id"`
-			AuthorName  string `bson:"author_name"`
-			CreatedAt   int64  `bson:"created_at"`
-			PublishedAt int64  `bson:"published_at"`
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var post postDocument
+		if err := cur.Decode(&post); err != nil {
+			return nil, fmt.Errorf("ошибка чтения строки: %w", err)
 		}
 
-		if err := cursor.Decode(&post); err != nil {
+		posts = append(posts, post.toPost())
+	}
+	if err := cur.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+
+	return posts, nil
+}
+
+// Post возвращает публикацию по ID.
+func (s *Store) Post(ctx context.Context, id int) (storage.Post, error) {
+	var post postDocument
+	err := s.Collection.FindOne(ctx, bson.M{"id": id, "deleted_at": 0}).Decode(&post)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return storage.Post{}, fmt.Errorf("публикация с ID %d: %w", id, storage.ErrNotFound)
+	}
+	if err != nil {
+		return storage.Post{}, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+
+	return post.toPost(), nil
+}
+
+// Search ищет публикации, удовлетворяющие фильтру, с помощью текстового индекса
+// и упорядочивает их по убыванию textScore.
+func (s *Store) Search(ctx context.Context, f storage.PostFilter, query string, limit, offset int) ([]storage.SearchResult, error) {
+	var results []storage.SearchResult
+
+	opts := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "id", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	filter := filterDocument(f)
+	filter["$text"] = bson.M{"$search": query}
+	cursor, err := s.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения поискового запроса: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	terms := storage.Terms(query)
+	for cursor.Next(ctx) {
+		var doc struct {
+			postDocument `bson:",inline"`
+			Score        float64 `bson:"score"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
 			return nil, fmt.Errorf("ошибка чтения строки: %w", err)
 		}
 
-		posts = append(posts, storage.Post{
-			ID:          post.ID,
-			Title:       post.Title,
-			Content:     post.Content,
-			AuthorID:    post.AuthorID,
-			AuthorName:  post.AuthorName,
-			CreatedAt:   post.CreatedAt,
-			PublishedAt: post.PublishedAt,
+		results = append(results, storage.SearchResult{
+			Post:    doc.toPost(),
+			Rank:    doc.Score,
+			Snippet: storage.Snippet(doc.Title, doc.Content, terms),
 		})
 	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
 
-	return posts, nil
+	return results, nil
 }
 
-// AddPost добавляет новую публикацию в базу данных.
-func (s *Store) AddPost(post storage.Post) error {
+// AddPost добавляет новую публикацию в базу данных
+// и сохраняет её первую ревизию.
+func (s *Store) AddPost(ctx context.Context, post storage.Post) error {
+	// Проверка на существование автора, имя автора хранится в публикации
+	author, err := s.Author(ctx, post.AuthorID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("автор с ID %d: %w", post.AuthorID, storage.ErrAuthorNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при проверке существования автора: %w", err)
+	}
 
-	nextID, err := getNextSequence(s.counters, "postID")
+	nextID, err := getNextSequence(ctx, s.counters, "postID")
 	if err != nil {
 		return err
 	}
 
-	newPost := struct {
-		ID          int    `bson:"id"`
-		Title       string `bson:"title"`
-		Content     string `bson:"content"`
-		AuthorID    int    `bson:"author_id"`
-		AuthorName  string `bson:"author_name"`
-		CreatedAt   int64  `bson:"created_at"`
-		PublishedAt int64  `bson:"published_at"`
-	}{
+	// Момент создания всегда проставляет хранилище: от него зависят
+	// курсор (created_at, id), фильтры по дате и порядок лент.
+	now := time.Now().Unix()
+	newPost := postDocument{
 		ID:          nextID,
 		Title:       post.Title,
 		Content:     post.Content,
-		AuthorID:    post.AuthorID,
-		AuthorName:  post.AuthorName,
-		CreatedAt:   post.CreatedAt,
+		AuthorID:    author.ID,
+		AuthorName:  author.Name,
+		Status:      post.Status,
+		CreatedAt:   now,
 		PublishedAt: post.PublishedAt,
+		UpdatedAt:   now,
+		Version:     1,
+	}
+	if newPost.Status == "" {
+		newPost.Status = storage.PublicationStatus(post.PublishedAt, now)
 	}
 
-	_, err = s.Collection.InsertOne(context.Background(), newPost)
+	_, err = s.Collection.InsertOne(ctx, newPost)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("публикация с ID %d уже существует: %w", nextID, storage.ErrConflict)
+	}
 	if err != nil {
 		return fmt.Errorf("ошибка при добавлении поста: %w", err)
 	}
 
-	return nil
+	return s.addRevision(ctx, newPost)
 }
 
-// UpdatePost обновляет существующую публикацию в базе данных.
-func (s *Store) UpdatePost(post storage.Post) error {
-	filter := bson.M{"id": post.ID} // Использовать ID как int
-	update := bson.M{
-		"$set": bson.M{
-			"title":        post.Title,
-			"content":      post.Content,
-			"author_id":    post.AuthorID,
-			"author_name":  post.AuthorName,
-			"created_at":   post.CreatedAt,
-			"published_at": post.PublishedAt,
-		},
+// UpdatePost обновляет непустые поля существующей публикации в базе данных
+// и сохраняет её новое состояние как ревизию.
+// Состояние и момент публикации меняются только через PublishPost.
+func (s *Store) UpdatePost(ctx context.Context, post storage.Post) error {
+	set := bson.M{}
+	if post.Title != "" {
+		set["title"] = post.Title
+	}
+	if post.Content != "" {
+		set["content"] = post.Content
+	}
+	if post.AuthorID != 0 {
+		// Имя автора берём из коллекции авторов, а не из запроса
+		author, err := s.Author(ctx, post.AuthorID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("автор с ID %d: %w", post.AuthorID, storage.ErrAuthorNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка при проверке существования автора: %w", err)
+		}
+		set["author_id"] = author.ID
+		set["author_name"] = author.Name
+	}
+	if post.CreatedAt != 0 {
+		set["created_at"] = post.CreatedAt
+	}
+
+	// Если не указаны поля для обновления, возвращаем ошибку
+	if len(set) == 0 {
+		return fmt.Errorf("нет полей для обновления: %w", storage.ErrInvalid)
 	}
+	set["updated_at"] = time.Now().Unix()
 
-	_, err := s.Collection.UpdateOne(context.Background(), filter, update)
+	filter := bson.M{"id": post.ID, "deleted_at": 0} // Использовать ID как int
+	if post.Version != 0 {
+		filter["version"] = post.Version
+	}
+	var doc postDocument
+	err := s.Collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": set, "$inc": bson.M{"version": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return s.notUpdated(ctx, post.ID)
+	}
 	if err != nil {
 		return fmt.Errorf("ошибка при обновлении поста: %w", err)
 	}
 
-	return nil
+	return s.addRevision(ctx, doc)
 }
 
-// DeletePost удаляет публикацию из базы данных.
-func (s *Store) DeletePost(post storage.Post) error {
-	_, err := s.Collection.DeleteOne(context.Background(), bson.M{"id": post.ID})
+// DeletePost перемещает публикацию в корзину.
+func (s *Store) DeletePost(ctx context.Context, post storage.Post) error {
+	filter := bson.M{"id": post.ID, "deleted_at": 0}
+	if post.Version != 0 {
+		filter["version"] = post.Version
+	}
+	now := time.Now().Unix()
+	res, err := s.Collection.UpdateOne(ctx, filter,
+		bson.M{"$set": bson.M{"deleted_at": now, "updated_at": now}, "$inc": bson.M{"version": 1}})
 	if err != nil {
 		return fmt.Errorf("ошибка при удалении поста: %w", err)
 	}
+	if res.MatchedCount == 0 {
+		return s.notUpdated(ctx, post.ID)
+	}
+
+	return nil
+}
+
+// PublishPost публикует публикацию в момент at: если он уже наступил,
+// публикация становится опубликованной, иначе - запланированной.
+func (s *Store) PublishPost(ctx context.Context, id int, at int64) error {
+	now := time.Now().Unix()
+	status := storage.PublicationStatus(at, now)
+	res, err := s.Collection.UpdateOne(ctx,
+		bson.M{"id": id, "deleted_at": 0},
+		bson.M{"$set": bson.M{"status": status, "published_at": at, "updated_at": now}, "$inc": bson.M{"version": 1}})
+	if err != nil {
+		return fmt.Errorf("ошибка при публикации поста: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("публикация с ID %d: %w", id, storage.ErrNotFound)
+	}
+
+	return nil
+}
+
+// PublishDue публикует запланированные публикации, момент публикации
+// которых не позже now, и возвращает их. Каждая публикация переводится
+// атомарно, поэтому одна и та же публикация не будет возвращена дважды.
+func (s *Store) PublishDue(ctx context.Context, now int64) ([]storage.Post, error) {
+	filter := bson.M{"status": storage.StatusScheduled, "published_at": bson.M{"$lte": now}, "deleted_at": 0}
+	update := bson.M{"$set": bson.M{"status": storage.StatusPublished, "updated_at": now}, "$inc": bson.M{"version": 1}}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetSort(bson.D{{Key: "published_at", Value: 1}})
+
+	var posts []storage.Post
+	for {
+		var doc postDocument
+		err := s.Collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return posts, nil
+		}
+		if err != nil {
+			return posts, fmt.Errorf("ошибка публикации запланированных постов: %w", err)
+		}
+		posts = append(posts, doc.toPost())
+	}
+}
+
+// RestorePost восстанавливает публикацию из корзины.
+func (s *Store) RestorePost(ctx context.Context, id int) error {
+	res, err := s.Collection.UpdateOne(ctx,
+		bson.M{"id": id, "deleted_at": bson.M{"$gt": 0}},
+		bson.M{"$set": bson.M{"deleted_at": 0, "updated_at": time.Now().Unix()}, "$inc": bson.M{"version": 1}})
+	if err != nil {
+		return fmt.Errorf("ошибка при восстановлении поста: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("публикация с ID %d в корзине: %w", id, storage.ErrNotFound)
+	}
 
 	return nil
 }
+
+// PurgePosts окончательно удаляет публикации, перемещённые в корзину
+// раньше before, и возвращает их количество.
+func (s *Store) PurgePosts(ctx context.Context, before int64) (int, error) {
+	n, err := s.deletePosts(ctx, bson.M{"deleted_at": bson.M{"$gt": 0, "$lt": before}})
+	if err != nil {
+		return n, fmt.Errorf("ошибка при очистке корзины: %w", err)
+	}
+
+	return n, nil
+}
+
+// notUpdated объясняет, почему запрос не изменил публикацию: её нет
+// (или она в корзине), либо её версия не совпала с указанной.
+func (s *Store) notUpdated(ctx context.Context, id int) error {
+	n, err := s.Collection.CountDocuments(ctx, bson.M{"id": id, "deleted_at": 0}, options.Count().SetLimit(1))
+	if err != nil {
+		return fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("публикация с ID %d: %w", id, storage.ErrNotFound)
+	}
+
+	return fmt.Errorf("публикация с ID %d: %w", id, storage.ErrStale)
+}
@@ -0,0 +1,152 @@
+package mongodb
+
+import (
+	"GoNews/pkg/storage"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// revisionAttempts - число попыток выбрать номер ревизии при параллельных изменениях.
+const revisionAttempts = 5
+
+// revisionDocument описывает представление ревизии в коллекции post_revisions.
+type revisionDocument struct {
+	PostID     int    `bson:"post_id"`
+	Number     int    `bson:"number"`
+	Title      string `bson:"title"`
+	Content    string `bson:"content"`
+	AuthorID   int    `bson:"author_id"`
+	AuthorName string `bson:"author_name"`
+	Editor     string `bson:"editor"`
+	CreatedAt  int64  `bson:"created_at"`
+}
+
+// toRevision преобразует документ в ревизию.
+func (d revisionDocument) toRevision() storage.Revision {
+	return storage.Revision{
+		PostID:     d.PostID,
+		Number:     d.Number,
+		Title:      d.Title,
+		Content:    d.Content,
+		AuthorID:   d.AuthorID,
+		AuthorName: d.AuthorName,
+		Editor:     d.Editor,
+		CreatedAt:  d.CreatedAt,
+	}
+}
+
+// addRevision сохраняет состояние публикации как очередную ревизию.
+// Номер ревизии на единицу больше последнего; если его одновременно занял
+// другой запрос, уникальный индекс (post_id, number) отклоняет вставку
+// и номер выбирается заново.
+func (s *Store) addRevision(ctx context.Context, p postDocument) error {
+	rev := revisionDocument{
+		PostID:     p.ID,
+		Title:      p.Title,
+		Content:    p.Content,
+		AuthorID:   p.AuthorID,
+		AuthorName: p.AuthorName,
+		Editor:     storage.EditorFrom(ctx),
+		CreatedAt:  time.Now().Unix(),
+	}
+
+	for i := 0; i < revisionAttempts; i++ {
+		var last revisionDocument
+		err := s.revisions.FindOne(ctx, bson.M{"post_id": p.ID},
+			options.FindOne().SetSort(bson.D{{Key: "number", Value: -1}})).Decode(&last)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("ошибка при чтении ревизий: %w", err)
+		}
+		rev.Number = last.Number + 1
+
+		_, err = s.revisions.InsertOne(ctx, rev)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка при сохранении ревизии: %w", err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("ревизия публикации с ID %d: %w", p.ID, storage.ErrConflict)
+}
+
+// Revisions возвращает все ревизии публикации по возрастанию номера.
+func (s *Store) Revisions(ctx context.Context, postID int) ([]storage.Revision, error) {
+	var revs []storage.Revision
+
+	opts := options.Find().SetSort(bson.D{{Key: "number", Value: 1}})
+	cursor, err := s.revisions.Find(ctx, bson.M{"post_id": postID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc revisionDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("ошибка чтения строки: %w", err)
+		}
+		revs = append(revs, doc.toRevision())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	if len(revs) > 0 {
+		return revs, nil
+	}
+
+	// Ревизий нет: публикации либо нет, либо она добавлена в обход хранилища.
+	n, err := s.Collection.CountDocuments(ctx, bson.M{"id": postID}, options.Count().SetLimit(1))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("публикация с ID %d: %w", postID, storage.ErrNotFound)
+	}
+
+	return []storage.Revision{}, nil
+}
+
+// Revision возвращает ревизию публикации по номеру.
+func (s *Store) Revision(ctx context.Context, postID, number int) (storage.Revision, error) {
+	var doc revisionDocument
+	err := s.revisions.FindOne(ctx, bson.M{"post_id": postID, "number": number}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return storage.Revision{}, fmt.Errorf("ревизия %d публикации с ID %d: %w", number, postID, storage.ErrNotFound)
+	}
+	if err != nil {
+		return storage.Revision{}, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+
+	return doc.toRevision(), nil
+}
+
+// deletePosts окончательно удаляет публикации, удовлетворяющие фильтру,
+// вместе с их ревизиями и возвращает количество удалённых публикаций.
+func (s *Store) deletePosts(ctx context.Context, filter bson.M) (int, error) {
+	ids, err := s.Collection.Distinct(ctx, "id", filter)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	res, err := s.Collection.DeleteMany(ctx, bson.M{"id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	if _, err := s.revisions.DeleteMany(ctx, bson.M{"post_id": bson.M{"$in": ids}}); err != nil {
+		return int(res.DeletedCount), err
+	}
+
+	return int(res.DeletedCount), nil
+}
@@ -2,13 +2,28 @@ package mongodb
 
 import (
 	"GoNews/pkg/storage"
+	"context"
 	"fmt"
 	"time"
 )
 
-// Seeder заполняет коллекцию начальными данными и возвращает список ошибок.
+// SeedPosts заполняет коллекции авторов и публикаций начальными данными
+// и возвращает список ошибок.
 func SeedPosts(store Store) []error {
 	var errors []error
+	ctx := context.Background()
+
+	// Сначала создаём авторов, публикации ссылаются на их ID
+	authorIDs := make(map[int]int)
+	for i, name := range []string{"Автор 1", "Автор 2", "Автор 3"} {
+		id, err := store.AddAuthor(ctx, storage.Author{Name: name})
+		if err != nil {
+			errors = append(errors, fmt.Errorf("ошибка при добавлении автора '%s': %v", name, err))
+			continue
+		}
+		authorIDs[i+1] = id
+		fmt.Printf("Автор '%s' успешно добавлен.\n", name)
+	}
 
 	// Данные для постов
 	posts := []storage.Post{
@@ -39,7 +54,12 @@ func SeedPosts(store Store) []error {
 	}
 
 	for _, post := range posts {
-		err := store.AddPost(post)
+		id, ok := authorIDs[post.AuthorID]
+		if !ok {
+			continue
+		}
+		post.AuthorID = id
+		err := store.AddPost(ctx, post)
 		if err != nil {
 			// Добавляем ошибку в список ошибок
 			errors = append(errors, fmt.Errorf("ошибка при добавлении поста '%s': %v", post.Title, err))
@@ -0,0 +1,187 @@
+package mongodb
+
+import (
+	"GoNews/pkg/storage"
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// userDocument описывает представление пользователя в коллекции MongoDB.
+// Имя пользователя не хранится: это имя связанного автора.
+type userDocument struct {
+	ID             int    `bson:"id"`
+	Email          string `bson:"email"`
+	PasswordHash   string `bson:"password_hash"`
+	Role           string `bson:"role"`
+	AuthorID       int    `bson:"author_id"`
+	CreatedAt      int64  `bson:"created_at"`
+	ResetTokenHash string `bson:"reset_token_hash"`
+	ResetExpiresAt int64  `bson:"reset_expires_at"`
+	TokenVersion   int    `bson:"token_version"`
+	OIDCIssuer     string `bson:"oidc_issuer,omitempty"`
+	OIDCSubject    string `bson:"oidc_subject,omitempty"`
+}
+
+// AddUser создаёт пользователя и связанного с ним автора u.Name.
+// Если адрес уже занят, созданный автор удаляется.
+func (s *Store) AddUser(ctx context.Context, u storage.User) (storage.User, error) {
+	n, err := s.users.CountDocuments(ctx, bson.M{"email": u.Email})
+	if err != nil {
+		return storage.User{}, fmt.Errorf("ошибка при проверке пользователя: %w", err)
+	}
+	if n > 0 {
+		return storage.User{}, fmt.Errorf("пользователь %s уже существует: %w", u.Email, storage.ErrConflict)
+	}
+
+	u.AuthorID, err = s.AddAuthor(ctx, storage.Author{Name: u.Name})
+	if err != nil {
+		return storage.User{}, err
+	}
+
+	u.ID, err = getNextSequence(ctx, s.counters, "userID")
+	if err == nil {
+		_, err = s.users.InsertOne(ctx, userDocument{
+			ID:           u.ID,
+			Email:        u.Email,
+			PasswordHash: u.PasswordHash,
+			Role:         u.Role,
+			AuthorID:     u.AuthorID,
+			CreatedAt:    u.CreatedAt,
+			OIDCIssuer:   u.OIDCIssuer,
+			OIDCSubject:  u.OIDCSubject,
+		})
+	}
+	if err != nil {
+		// Транзакции доступны не на всех развёртываниях MongoDB,
+		// поэтому автор, созданный для пользователя, удаляется явно.
+		s.authors.DeleteOne(ctx, bson.M{"id": u.AuthorID})
+		if mongo.IsDuplicateKeyError(err) {
+			return storage.User{}, fmt.Errorf("пользователь %s или его учётная запись OIDC уже существует: %w", u.Email, storage.ErrConflict)
+		}
+		return storage.User{}, fmt.Errorf("ошибка при добавлении пользователя: %w", err)
+	}
+
+	return u, nil
+}
+
+// User возвращает пользователя по ID.
+func (s *Store) User(ctx context.Context, id int) (storage.User, error) {
+	return s.findUser(ctx, bson.M{"id": id})
+}
+
+// UserByEmail возвращает пользователя по адресу электронной почты.
+func (s *Store) UserByEmail(ctx context.Context, email string) (storage.User, error) {
+	return s.findUser(ctx, bson.M{"email": email})
+}
+
+// UserByResetToken возвращает пользователя по хешу токена сброса пароля.
+func (s *Store) UserByResetToken(ctx context.Context, hash string) (storage.User, error) {
+	if hash == "" {
+		return storage.User{}, fmt.Errorf("пользователь: %w", storage.ErrNotFound)
+	}
+	return s.findUser(ctx, bson.M{"reset_token_hash": hash})
+}
+
+// UserByOIDC возвращает пользователя, привязанного к учётной записи subject
+// провайдера OpenID Connect issuer.
+func (s *Store) UserByOIDC(ctx context.Context, issuer, subject string) (storage.User, error) {
+	if subject == "" {
+		return storage.User{}, fmt.Errorf("пользователь: %w", storage.ErrNotFound)
+	}
+	return s.findUser(ctx, bson.M{"oidc_issuer": issuer, "oidc_subject": subject})
+}
+
+// LinkOIDC привязывает к пользователю учётную запись провайдера OpenID Connect.
+func (s *Store) LinkOIDC(ctx context.Context, id int, issuer, subject string) error {
+	err := s.updateUser(ctx, id, bson.M{"$set": bson.M{
+		"oidc_issuer":  issuer,
+		"oidc_subject": subject,
+	}})
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("учётная запись OIDC уже привязана: %w", storage.ErrConflict)
+	}
+	return err
+}
+
+// findUser возвращает пользователя, удовлетворяющего фильтру,
+// с именем связанного автора.
+func (s *Store) findUser(ctx context.Context, filter bson.M) (storage.User, error) {
+	var doc userDocument
+	err := s.users.FindOne(ctx, filter).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return storage.User{}, fmt.Errorf("пользователь: %w", storage.ErrNotFound)
+	}
+	if err != nil {
+		return storage.User{}, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+
+	u := storage.User{
+		ID:             doc.ID,
+		Email:          doc.Email,
+		PasswordHash:   doc.PasswordHash,
+		Role:           doc.Role,
+		AuthorID:       doc.AuthorID,
+		CreatedAt:      doc.CreatedAt,
+		ResetTokenHash: doc.ResetTokenHash,
+		ResetExpiresAt: doc.ResetExpiresAt,
+		TokenVersion:   doc.TokenVersion,
+		OIDCIssuer:     doc.OIDCIssuer,
+		OIDCSubject:    doc.OIDCSubject,
+	}
+	if u.AuthorID != 0 {
+		author, err := s.Author(ctx, u.AuthorID)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return storage.User{}, err
+		}
+		u.Name = author.Name
+	}
+
+	return u, nil
+}
+
+// SetPassword меняет хеш пароля пользователя, аннулирует токен сброса
+// и увеличивает версию токенов пользователя. У документов, созданных
+// до появления поля token_version, $inc создаёт его со значением 1.
+func (s *Store) SetPassword(ctx context.Context, id int, hash string) error {
+	return s.updateUser(ctx, id, bson.M{
+		"$set": bson.M{
+			"password_hash":    hash,
+			"reset_token_hash": "",
+			"reset_expires_at": int64(0),
+		},
+		"$inc": bson.M{"token_version": 1},
+	})
+}
+
+// SetResetToken сохраняет хеш токена сброса пароля и момент его истечения.
+func (s *Store) SetResetToken(ctx context.Context, id int, hash string, expiresAt int64) error {
+	return s.updateUser(ctx, id, bson.M{"$set": bson.M{
+		"reset_token_hash": hash,
+		"reset_expires_at": expiresAt,
+	}})
+}
+
+// SetUserRole меняет роль пользователя и увеличивает версию его токенов.
+func (s *Store) SetUserRole(ctx context.Context, id int, role string) error {
+	return s.updateUser(ctx, id, bson.M{
+		"$set": bson.M{"role": role},
+		"$inc": bson.M{"token_version": 1},
+	})
+}
+
+// updateUser применяет изменение update к пользователю с ID id.
+func (s *Store) updateUser(ctx context.Context, id int, update bson.M) error {
+	res, err := s.users.UpdateOne(ctx, bson.M{"id": id}, update)
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении пользователя: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("пользователь с ID %d: %w", id, storage.ErrNotFound)
+	}
+
+	return nil
+}
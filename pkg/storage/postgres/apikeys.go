@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"GoNews/pkg/storage"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// apiKeyColumns - столбцы таблицы api_keys в порядке полей storage.APIKey.
+const apiKeyColumns = `id, name, hash, scopes, role, COALESCE(author_id, 0), created_at, revoked_at`
+
+// scanAPIKey читает ключ API из строки результата запроса.
+func scanAPIKey(row pgx.Row) (storage.APIKey, error) {
+	var k storage.APIKey
+	err := row.Scan(&k.ID, &k.Name, &k.Hash, &k.Scopes, &k.Role, &k.AuthorID, &k.CreatedAt, &k.RevokedAt)
+	return k, err
+}
+
+// APIKeys возвращает все ключи API, упорядоченные по ID.
+func (s *Store) APIKeys(ctx context.Context) ([]storage.APIKey, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []storage.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения строки: %w", err)
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, rows.Err()
+}
+
+// APIKeyByHash возвращает ключ API по хешу.
+func (s *Store) APIKeyByHash(ctx context.Context, hash string) (storage.APIKey, error) {
+	k, err := scanAPIKey(s.db.QueryRow(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE hash = $1`, hash))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return storage.APIKey{}, fmt.Errorf("ключ API: %w", storage.ErrNotFound)
+	}
+	if err != nil {
+		return storage.APIKey{}, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+
+	return k, nil
+}
+
+// AddAPIKey сохраняет ключ API и возвращает его ID.
+func (s *Store) AddAPIKey(ctx context.Context, k storage.APIKey) (int, error) {
+	var id int
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO api_keys (name, hash, scopes, role, author_id, created_at, revoked_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, 0), $6, $7) RETURNING id`,
+		k.Name, k.Hash, k.Scopes, k.Role, k.AuthorID, k.CreatedAt, k.RevokedAt).Scan(&id)
+	if pgCode(err) == uniqueViolation {
+		return 0, fmt.Errorf("ключ API уже существует: %w", storage.ErrConflict)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при добавлении ключа API: %w", err)
+	}
+
+	return id, nil
+}
+
+// RevokeAPIKey отзывает ключ API.
+func (s *Store) RevokeAPIKey(ctx context.Context, id int, at int64) error {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE api_keys SET revoked_at = CASE WHEN revoked_at = 0 THEN $2 ELSE revoked_at END
+		WHERE id = $1`, id, at)
+	if err != nil {
+		return fmt.Errorf("ошибка при отзыве ключа API: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("ключ API с ID %d: %w", id, storage.ErrNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"GoNews/pkg/storage"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// Authors возвращает всех авторов, упорядоченных по ID.
+func (s *Store) Authors(ctx context.Context) ([]storage.Author, error) {
+	rows, err := s.db.Query(ctx, `SELECT id, name FROM authors ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer rows.Close()
+
+	var authors []storage.Author
+	for rows.Next() {
+		var a storage.Author
+		if err := rows.Scan(&a.ID, &a.Name); err != nil {
+			return nil, fmt.Errorf("ошибка чтения строки: %w", err)
+		}
+		authors = append(authors, a)
+	}
+
+	return authors, rows.Err()
+}
+
+// Author возвращает автора по ID.
+func (s *Store) Author(ctx context.Context, id int) (storage.Author, error) {
+	var a storage.Author
+	err := s.db.QueryRow(ctx, `SELECT id, name FROM authors WHERE id = $1`, id).Scan(&a.ID, &a.Name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return storage.Author{}, fmt.Errorf("автор с ID %d: %w", id, storage.ErrNotFound)
+	}
+	if err != nil {
+		return storage.Author{}, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+
+	return a, nil
+}
+
+// AddAuthor добавляет нового автора и возвращает его ID.
+// Имена авторов не уникальны: у разных людей может быть одно имя.
+func (s *Store) AddAuthor(ctx context.Context, author storage.Author) (int, error) {
+	var id int
+	err := s.db.QueryRow(ctx, `INSERT INTO authors (name) VALUES ($1) RETURNING id`, author.Name).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при добавлении автора: %w", err)
+	}
+
+	return id, nil
+}
+
+// UpdateAuthor переименовывает автора. Имя автора входит в его публикации,
+// поэтому их версия и момент изменения тоже обновляются: иначе клиенты
+// сочли бы сохранённую копию актуальной.
+func (s *Store) UpdateAuthor(ctx context.Context, author storage.Author) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var name string
+	err = tx.QueryRow(ctx, `SELECT name FROM authors WHERE id = $1 FOR UPDATE`, author.ID).Scan(&name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("автор с ID %d: %w", author.ID, storage.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при проверке существования автора: %w", err)
+	}
+	if name == author.Name {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE authors SET name = $1 WHERE id = $2`, author.Name, author.ID); err != nil {
+		return fmt.Errorf("ошибка при обновлении автора: %w", err)
+	}
+	_, err = tx.Exec(ctx, `UPDATE posts SET version = version + 1, updated_at = $1 WHERE author_id = $2`,
+		time.Now().Unix(), author.ID)
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении публикаций автора: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeleteAuthor удаляет автора. Если у автора есть публикации,
+// они удаляются при cascade, иначе возвращается ErrAuthorHasPosts.
+// Учётные записи пользователей и ключи API автора остаются без связанного автора.
+func (s *Store) DeleteAuthor(ctx context.Context, id int, cascade bool) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Блокируем автора, чтобы параллельно не добавились новые публикации.
+	var exists bool
+	err = tx.QueryRow(ctx, `SELECT true FROM authors WHERE id = $1 FOR UPDATE`, id).Scan(&exists)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("автор с ID %d: %w", id, storage.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при проверке существования автора: %w", err)
+	}
+
+	if cascade {
+		if _, err := tx.Exec(ctx, `DELETE FROM posts WHERE author_id = $1`, id); err != nil {
+			return fmt.Errorf("ошибка при удалении публикаций автора: %w", err)
+		}
+	} else {
+		var hasPosts bool
+		err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM posts WHERE author_id = $1)`, id).Scan(&hasPosts)
+		if err != nil {
+			return fmt.Errorf("ошибка при проверке публикаций автора: %w", err)
+		}
+		if hasPosts {
+			return fmt.Errorf("автор с ID %d: %w", id, storage.ErrAuthorHasPosts)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM authors WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("ошибка при удалении автора: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// lockClassID - первая часть ключа advisory-блокировок фоновых задач,
+// вторая часть - хеш имени блокировки.
+const lockClassID = 7_238_410
+
+// TryLock захватывает advisory-блокировку name на уровне сессии.
+// Блокировка удерживается на отдельном соединении из пула до вызова unlock
+// и снимается сервером БД при разрыве соединения, поэтому ttl не используется.
+func (s *Store) TryLock(ctx context.Context, name string, ttl time.Duration) (func(), bool, error) {
+	conn, err := s.db.Acquire(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("не удалось получить соединение: %w", err)
+	}
+
+	var ok bool
+	err = conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1, hashtext($2))`, lockClassID, name).Scan(&ok)
+	if err != nil || !ok {
+		conn.Release()
+		if err != nil {
+			return nil, false, fmt.Errorf("не удалось захватить блокировку %s: %w", name, err)
+		}
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		defer conn.Release()
+		_, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1, hashtext($2))`, lockClassID, name)
+		if err != nil {
+			// Соединение с неснятой блокировкой нельзя возвращать в пул.
+			conn.Conn().Close(context.Background())
+		}
+	}
+	return unlock, true, nil
+}
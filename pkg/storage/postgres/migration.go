@@ -2,45 +2,255 @@ package postgres
 
 import (
 	"context"
+	"embed"
 	"fmt"
-	"io"
-	"os"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
 	"time"
+
+	"github.com/jackc/pgx/v4"
 )
 
-func Migrate(dsn string) error {
+// Файлы миграций встраиваются в бинарный файл. Имя файла имеет вид
+// NNNN_описание.up.sql или NNNN_описание.down.sql, где NNNN - номер версии.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID - ключ advisory-блокировки, которую держит процесс,
+// выполняющий миграции, чтобы одновременно запущенные экземпляры
+// сервера не применяли миграции параллельно.
+const migrationLockID = 7_238_410_001
+
+// migrationTimeout - предельное время выполнения всех миграций.
+const migrationTimeout = 5 * time.Minute
+
+var migrationName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// migration - версия схемы базы данных.
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// MigrationStatus - состояние миграции в базе данных.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt int64 // 0, если миграция не применена
+}
+
+// loadMigrations читает встроенные файлы миграций, упорядочивая их по версии.
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать каталог миграций: %w", err)
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, e := range entries {
+		m := migrationName.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("некорректное имя файла миграции %s", e.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
 
-	// Инициализируем подключение к базе данных
-	if err := InitDB(dsn); err != nil {
+		sql, err := fs.ReadFile(migrationFiles, path.Join("migrations", e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения файла %s: %w", e.Name(), err)
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &migration{version: version, name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.name != m[2] {
+			return nil, fmt.Errorf("у миграции %d разные имена: %s и %s", version, mig.name, m[2])
+		}
+		if m[3] == "up" {
+			mig.up = string(sql)
+		} else {
+			mig.down = string(sql)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.up == "" || mig.down == "" {
+			return nil, fmt.Errorf("у миграции %d должны быть файлы up и down", mig.version)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+
+	return migrations, nil
+}
+
+// withMigrationLock подключается к базе данных, создаёт таблицу
+// schema_migrations и выполняет fn, удерживая advisory-блокировку.
+func withMigrationLock(dsn string, fn func(ctx context.Context, conn *pgx.Conn) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), migrationTimeout)
+	defer cancel()
+
+	// Advisory-блокировка принадлежит сессии, поэтому используем одно соединение, а не пул.
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
 		return fmt.Errorf("не удалось подключиться к базе данных: %w", err)
 	}
-	defer CloseDB() // Закрываем подключение в конце
+	defer conn.Close(context.Background())
 
-	// Открываем файл с миграциями
-	file, err := os.Open("schema.sql")
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("не удалось получить блокировку миграций: %w", err)
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	_, err = conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at BIGINT NOT NULL
+		)`)
 	if err != nil {
-		return fmt.Errorf("не удалось открыть файл schema.sql: %w", err)
+		return fmt.Errorf("не удалось создать таблицу schema_migrations: %w", err)
 	}
-	defer file.Close()
 
-	// Читаем содержимое файла с миграциями
-	sqlBytes, err := io.ReadAll(file)
+	return fn(ctx, conn)
+}
+
+// appliedMigrations возвращает время применения миграций по их версиям.
+func appliedMigrations(ctx context.Context, conn *pgx.Conn) (map[int]int64, error) {
+	rows, err := conn.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
 	if err != nil {
-		return fmt.Errorf("ошибка чтения файла schema.sql: %w", err)
+		return nil, fmt.Errorf("ошибка чтения schema_migrations: %w", err)
 	}
-	sql := string(sqlBytes)
+	defer rows.Close()
 
-	// Настраиваем контекст для выполнения запроса
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	applied := make(map[int]int64)
+	for rows.Next() {
+		var version int
+		var at int64
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("ошибка чтения строки: %w", err)
+		}
+		applied[version] = at
+	}
 
-	// Выполняем SQL-скрипт миграции
-	_, err = DBPool.Exec(ctx, sql)
+	return applied, rows.Err()
+}
+
+// Migrate применяет все ещё не применённые миграции по возрастанию версий.
+// Каждая миграция выполняется в отдельной транзакции, поэтому повторный
+// запуск продолжает с первой неприменённой миграции.
+func Migrate(dsn string) error {
+	migrations, err := loadMigrations()
 	if err != nil {
-		return fmt.Errorf("ошибка выполнения миграции: %w", err)
+		return err
 	}
 
-	// Сообщаем об успешной миграции
-	fmt.Println("Миграция выполнена успешно!")
-	return nil
+	return withMigrationLock(dsn, func(ctx context.Context, conn *pgx.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if _, ok := applied[m.version]; ok {
+				continue
+			}
+
+			err := conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, m.up); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx,
+					`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`,
+					m.version, m.name, time.Now().Unix())
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("ошибка выполнения миграции %04d_%s: %w", m.version, m.name, err)
+			}
+			fmt.Printf("Применена миграция %04d_%s.\n", m.version, m.name)
+		}
+
+		// Сообщаем об успешной миграции
+		fmt.Println("Миграция выполнена успешно!")
+		return nil
+	})
+}
+
+// Rollback откатывает применённые миграции с версиями больше target
+// по убыванию версий. Rollback(dsn, 0) откатывает все миграции.
+func Rollback(dsn string, target int) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	return withMigrationLock(dsn, func(ctx context.Context, conn *pgx.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0; i-- {
+			m := migrations[i]
+			if m.version <= target {
+				break
+			}
+			if _, ok := applied[m.version]; !ok {
+				continue
+			}
+
+			err := conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, m.down); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("ошибка отката миграции %04d_%s: %w", m.version, m.name, err)
+			}
+			fmt.Printf("Откачена миграция %04d_%s.\n", m.version, m.name)
+		}
+
+		return nil
+	})
+}
+
+// Status возвращает состояние всех известных миграций.
+func Status(dsn string) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var status []MigrationStatus
+	err = withMigrationLock(dsn, func(ctx context.Context, conn *pgx.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			status = append(status, MigrationStatus{Version: m.version, Name: m.name, AppliedAt: applied[m.version]})
+			delete(applied, m.version)
+		}
+		// Миграции, применённые более новой версией сервера
+		for version, at := range applied {
+			status = append(status, MigrationStatus{Version: version, Name: "(неизвестна)", AppliedAt: at})
+		}
+		sort.Slice(status, func(i, j int) bool { return status[i].Version < status[j].Version })
+
+		return nil
+	})
+
+	return status, err
 }
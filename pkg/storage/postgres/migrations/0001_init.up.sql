@@ -0,0 +1,41 @@
+-- Начальная схема. Миграция применяется и к базам, созданным до появления
+-- версионирования из schema.sql: таблицы там уже есть, поэтому недостающие
+-- в них столбцы добавляются отдельно через ALTER TABLE.
+CREATE TABLE IF NOT EXISTS authors (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS posts (
+    id SERIAL PRIMARY KEY,
+    author_id INTEGER REFERENCES authors(id) NOT NULL,
+    title TEXT  NOT NULL,
+    content TEXT NOT NULL,
+    created_at BIGINT NOT NULL
+);
+
+-- В schema.sql момента публикации не было, и все публикации были видны
+-- читателям: считаем их опубликованными в момент создания.
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'posts' AND column_name = 'published_at'
+    ) THEN
+        ALTER TABLE posts ADD COLUMN published_at BIGINT NOT NULL DEFAULT 0;
+        UPDATE posts SET published_at = created_at;
+    END IF;
+END
+$$;
+
+-- Поисковый вектор: публикации в основном на русском, но встречается и английский текст.
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('russian', title), 'A') ||
+    setweight(to_tsvector('english', title), 'A') ||
+    setweight(to_tsvector('russian', content), 'B') ||
+    setweight(to_tsvector('english', content), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS posts_created_at_id_idx ON posts (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS posts_author_id_idx ON posts (author_id);
+CREATE INDEX IF NOT EXISTS posts_search_vector_idx ON posts USING GIN (search_vector);
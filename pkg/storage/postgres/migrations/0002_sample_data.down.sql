@@ -0,0 +1,2 @@
+-- Миграция не изменяет данных, откатывать нечего: строки с ID демонстрационных
+-- данных могут принадлежать настоящим авторам и публикациям.
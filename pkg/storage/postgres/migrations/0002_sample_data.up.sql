@@ -0,0 +1,3 @@
+-- Демонстрационные данные перенесены из миграций в сидирование (флаг -seed),
+-- миграции изменяют только схему. Версия сохранена, чтобы не менять нумерацию
+-- миграций в уже развёрнутых базах.
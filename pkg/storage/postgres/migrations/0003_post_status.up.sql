@@ -0,0 +1,10 @@
+ALTER TABLE posts ADD COLUMN status TEXT NOT NULL DEFAULT 'draft'
+    CHECK (status IN ('draft', 'scheduled', 'published'));
+
+UPDATE posts SET status = CASE
+    WHEN published_at = 0 THEN 'draft'
+    WHEN published_at > extract(epoch FROM now()) THEN 'scheduled'
+    ELSE 'published'
+END;
+
+CREATE INDEX posts_status_published_at_idx ON posts (status, published_at);
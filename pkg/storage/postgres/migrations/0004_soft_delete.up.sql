@@ -0,0 +1,3 @@
+ALTER TABLE posts ADD COLUMN deleted_at BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX posts_deleted_at_idx ON posts (deleted_at) WHERE deleted_at <> 0;
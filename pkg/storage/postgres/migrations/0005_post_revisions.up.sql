@@ -0,0 +1,18 @@
+-- Ревизии хранят имя автора на момент изменения и не ссылаются на authors,
+-- поэтому удаление или переименование автора не меняет историю.
+CREATE TABLE post_revisions (
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    number INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    author_id INTEGER NOT NULL,
+    author_name TEXT NOT NULL,
+    editor TEXT NOT NULL DEFAULT '',
+    created_at BIGINT NOT NULL,
+    PRIMARY KEY (post_id, number)
+);
+
+-- Текущее состояние существующих публикаций становится их первой ревизией.
+INSERT INTO post_revisions (post_id, number, title, content, author_id, author_name, created_at)
+SELECT p.id, 1, p.title, p.content, p.author_id, a.name, p.created_at
+FROM posts p JOIN authors a ON p.author_id = a.id;
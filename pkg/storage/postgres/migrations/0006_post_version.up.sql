@@ -0,0 +1 @@
+ALTER TABLE posts ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
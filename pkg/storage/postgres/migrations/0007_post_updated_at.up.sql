@@ -0,0 +1,3 @@
+ALTER TABLE posts ADD COLUMN updated_at BIGINT NOT NULL DEFAULT 0;
+
+UPDATE posts SET updated_at = GREATEST(created_at, published_at, deleted_at);
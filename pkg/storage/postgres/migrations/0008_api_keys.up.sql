@@ -0,0 +1,9 @@
+-- Ключи API хранятся в виде хеша SHA-256, сам ключ в БД не попадает.
+CREATE TABLE api_keys (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    hash TEXT NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL,
+    created_at BIGINT NOT NULL,
+    revoked_at BIGINT NOT NULL DEFAULT 0
+);
@@ -0,0 +1,13 @@
+-- При удалении автора учётная запись пользователя остаётся без автора.
+CREATE TABLE users (
+    id SERIAL PRIMARY KEY,
+    email TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    role TEXT NOT NULL DEFAULT 'author',
+    author_id INTEGER REFERENCES authors(id) ON DELETE SET NULL,
+    created_at BIGINT NOT NULL,
+    reset_token_hash TEXT NOT NULL DEFAULT '',
+    reset_expires_at BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX users_reset_token_hash_idx ON users (reset_token_hash) WHERE reset_token_hash <> '';
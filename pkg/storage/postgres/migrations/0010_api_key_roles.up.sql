@@ -0,0 +1,8 @@
+-- Раньше ключ с разрешением write действовал с ролью editor. Теперь роль
+-- задаётся при выпуске, а прежние ключи получают наименьшие права:
+-- роль admin остаётся только у ключей с разрешением admin.
+ALTER TABLE api_keys
+    ADD COLUMN role TEXT NOT NULL DEFAULT 'author',
+    ADD COLUMN author_id INTEGER REFERENCES authors(id) ON DELETE SET NULL;
+
+UPDATE api_keys SET role = 'admin' WHERE 'admin' = ANY (scopes);
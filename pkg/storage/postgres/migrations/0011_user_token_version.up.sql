@@ -0,0 +1,3 @@
+-- Версия токенов пользователя увеличивается при смене пароля:
+-- JWT, выпущенные с прежней версией, перестают действовать.
+ALTER TABLE users ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN oidc_issuer, DROP COLUMN oidc_subject;
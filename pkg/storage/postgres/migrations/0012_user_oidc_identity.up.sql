@@ -0,0 +1,7 @@
+-- Учётная запись провайдера OpenID Connect (iss и sub ID-токена), через которую
+-- входит пользователь. Пустой sub - вход через OIDC не привязан.
+ALTER TABLE users
+    ADD COLUMN oidc_issuer TEXT NOT NULL DEFAULT '',
+    ADD COLUMN oidc_subject TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX users_oidc_identity_idx ON users (oidc_issuer, oidc_subject) WHERE oidc_subject <> '';
@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"GoNews/pkg/storage"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// Коды ошибок PostgreSQL, которые соответствуют ошибкам хранилища.
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// pgCode возвращает код ошибки PostgreSQL или пустую строку,
+// если ошибка возникла не на стороне сервера БД.
+func pgCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// postColumns - список полей публикации, выбираемых вместе с именем автора.
+const postColumns = `p.id, p.title, p.content, p.author_id, a.name, p.status, p.created_at, p.published_at, p.updated_at, p.deleted_at, p.version`
+
+// postsFrom - источник публикаций с присоединённой таблицей авторов.
+const postsFrom = `FROM posts p JOIN authors a ON p.author_id = a.id`
+
+// sortColumns сопоставляет поля сортировки столбцам таблицы posts.
+var sortColumns = map[string]string{
+	storage.SortByID:          "p.id",
+	storage.SortByCreatedAt:   "p.created_at",
+	storage.SortByPublishedAt: "p.published_at",
+	storage.SortByTitle:       "p.title",
+	storage.SortByDeletedAt:   "p.deleted_at",
+}
+
+// scanPost читает публикацию из строки, выбранной по postColumns.
+func scanPost(row pgx.Row) (storage.Post, error) {
+	var post storage.Post
+	err := row.Scan(&post.ID, &post.Title, &post.Content, &post.AuthorID, &post.AuthorName, &post.Status, &post.CreatedAt, &post.PublishedAt, &post.UpdatedAt, &post.DeletedAt, &post.Version)
+	return post, err
+}
+
+// scanPosts читает все публикации из результата запроса.
+func scanPosts(rows pgx.Rows) ([]storage.Post, error) {
+	defer rows.Close()
+
+	var posts []storage.Post
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения строки: %w", err)
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+
+	return posts, nil
+}
+
+// whereClause строит условия WHERE по фильтру. Параметры запроса
+// добавляются к args, нумерация плейсхолдеров продолжает уже имеющиеся.
+func whereClause(f storage.PostFilter, args []interface{}) ([]string, []interface{}) {
+	conds := []string{"p.deleted_at = 0"}
+	if f.Deleted {
+		conds[0] = "p.deleted_at <> 0"
+	}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.AuthorID != 0 {
+		add("p.author_id = $%d", f.AuthorID)
+	}
+	if f.CreatedAfter != 0 {
+		add("p.created_at >= $%d", f.CreatedAfter)
+	}
+	if f.CreatedBefore != 0 {
+		add("p.created_at < $%d", f.CreatedBefore)
+	}
+	if f.PublishedAfter != 0 {
+		add("p.published_at >= $%d", f.PublishedAfter)
+	}
+	if f.Status != "" {
+		add("p.status = $%d", f.Status)
+	}
+	if f.TitleContains != "" {
+		add("strpos(lower(p.title), lower($%d)) > 0", f.TitleContains)
+	}
+
+	return conds, args
+}
+
+// where объединяет условия в выражение WHERE.
+func where(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
+}
+
+// orderClause строит выражение ORDER BY по фильтру.
+// При равенстве значений поля публикации упорядочиваются по ID.
+func orderClause(f storage.PostFilter) string {
+	column, ok := sortColumns[f.SortBy]
+	if !ok {
+		column = sortColumns[storage.SortByID]
+	}
+	dir := "ASC"
+	if f.SortDesc {
+		dir = "DESC"
+	}
+	if column == "p.id" {
+		return fmt.Sprintf(" ORDER BY p.id %s", dir)
+	}
+	return fmt.Sprintf(" ORDER BY %s %s, p.id %s", column, dir, dir)
+}
+
+// querier - общий интерфейс пула соединений и транзакции.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// notUpdated объясняет, почему запрос не изменил публикацию: её нет
+// (или она в корзине), либо её версия не совпала с указанной.
+func notUpdated(ctx context.Context, q querier, id int) error {
+	var exists bool
+	err := q.QueryRow(ctx, `SELECT true FROM posts WHERE id = $1 AND deleted_at = 0`, id).Scan(&exists)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("публикация с ID %d: %w", id, storage.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+
+	return fmt.Errorf("публикация с ID %d: %w", id, storage.ErrStale)
+}
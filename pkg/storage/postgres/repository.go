@@ -2,12 +2,14 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"GoNews/pkg/storage"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -65,56 +67,158 @@ func (s *Store) Close() {
 	}
 }
 
-// Posts возвращает все публикации из базы данных, включая информацию об авторах.
-func (s *Store) Posts() ([]storage.Post, error) {
-	rows, err := s.db.Query(context.Background(), `
-		SELECT p.id, p.title, p.content, p.author_id, a.name, p.created_at 
-		FROM posts p
-		JOIN authors a ON p.author_id = a.id`)
+// Posts возвращает страницу публикаций, удовлетворяющих фильтру,
+// включая информацию об авторах, и общее количество таких публикаций.
+func (s *Store) Posts(ctx context.Context, f storage.PostFilter, limit, offset int) ([]storage.Post, int, error) {
+	conds, args := whereClause(f, nil)
+
+	var total int
+	err := s.db.QueryRow(ctx, `SELECT count(*) `+postsFrom+where(conds), args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка подсчёта публикаций: %w", err)
+	}
+
+	args = append(args, limit, offset)
+	query := `SELECT ` + postColumns + ` ` + postsFrom + where(conds) + orderClause(f) +
+		fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+
+	posts, err := scanPosts(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return posts, total, nil
+}
+
+// PostsAfter возвращает до limit публикаций, удовлетворяющих фильтру
+// и следующих за курсором, в порядке убывания (created_at, id).
+// Используется keyset-запрос, поэтому вставка новых публикаций
+// не сдвигает уже прочитанную ленту. Сортировка из фильтра не применяется.
+func (s *Store) PostsAfter(ctx context.Context, f storage.PostFilter, cursor *storage.Cursor, limit int) ([]storage.Post, error) {
+	conds, args := whereClause(f, nil)
+	if cursor != nil {
+		args = append(args, cursor.CreatedAt, cursor.ID)
+		conds = append(conds, fmt.Sprintf("(p.created_at, p.id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+	args = append(args, limit)
+	query := `SELECT ` + postColumns + ` ` + postsFrom + where(conds) +
+		fmt.Sprintf(" ORDER BY p.created_at DESC, p.id DESC LIMIT $%d", len(args))
+
+	rows, err := s.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
 	}
+
+	return scanPosts(rows)
+}
+
+// Post возвращает публикацию по ID вместе с именем автора.
+func (s *Store) Post(ctx context.Context, id int) (storage.Post, error) {
+	row := s.db.QueryRow(ctx, `SELECT `+postColumns+` `+postsFrom+` WHERE p.id = $1 AND p.deleted_at = 0`, id)
+	post, err := scanPost(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return storage.Post{}, fmt.Errorf("публикация с ID %d: %w", id, storage.ErrNotFound)
+	}
+	if err != nil {
+		return storage.Post{}, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+
+	return post, nil
+}
+
+// Search ищет публикации, удовлетворяющие фильтру, по заголовку и тексту
+// с помощью полнотекстового индекса. Запрос разбирается одновременно в русской и английской
+// конфигурациях, результаты упорядочены по убыванию ts_rank.
+// Фрагменты текста строятся так же, как в остальных хранилищах, см. storage.Snippet.
+func (s *Store) Search(ctx context.Context, f storage.PostFilter, query string, limit, offset int) ([]storage.SearchResult, error) {
+	conds, args := whereClause(f, []interface{}{query, limit, offset})
+	conds = append(conds, "p.search_vector @@ q.query")
+	rows, err := s.db.Query(ctx, `
+		SELECT `+postColumns+`,
+			ts_rank(p.search_vector, q.query)::float8 AS rank
+		`+postsFrom+`
+		CROSS JOIN (SELECT websearch_to_tsquery('russian', $1) || websearch_to_tsquery('english', $1) AS query) q`+
+		where(conds)+`
+		ORDER BY rank DESC, p.id DESC
+		LIMIT $2 OFFSET $3`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения поискового запроса: %w", err)
+	}
 	defer rows.Close()
 
-	var posts []storage.Post
+	terms := storage.Terms(query)
+	var results []storage.SearchResult
 	for rows.Next() {
-		var post storage.Post
-		err := rows.Scan(&post.ID, &post.Title, &post.Content, &post.AuthorID, &post.AuthorName, &post.CreatedAt)
+		var r storage.SearchResult
+		err := rows.Scan(&r.ID, &r.Title, &r.Content, &r.AuthorID, &r.AuthorName, &r.Status, &r.CreatedAt, &r.PublishedAt, &r.UpdatedAt, &r.DeletedAt, &r.Version, &r.Rank)
 		if err != nil {
 			return nil, fmt.Errorf("ошибка чтения строки: %w", err)
 		}
-		posts = append(posts, post)
+		r.Snippet = storage.Snippet(r.Title, r.Content, terms)
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
 	}
 
-	return posts, nil
+	return results, nil
 }
 
-// AddPost добавляет новую публикацию в базу данных.
-func (s *Store) AddPost(post storage.Post) error {
+// AddPost добавляет новую публикацию в базу данных
+// и сохраняет её первую ревизию.
+func (s *Store) AddPost(ctx context.Context, post storage.Post) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	// Проверка на существование автора
 	var authorExists bool
-	err := s.db.QueryRow(context.Background(), `SELECT EXISTS(SELECT 1 FROM authors WHERE id = $1)`, post.AuthorID).Scan(&authorExists)
+	err = tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM authors WHERE id = $1)`, post.AuthorID).Scan(&authorExists)
 	if err != nil {
 		return fmt.Errorf("ошибка при проверке существования автора: %w", err)
 	}
 
 	if !authorExists {
-		return fmt.Errorf("автор с ID %d не существует", post.AuthorID)
+		return fmt.Errorf("автор с ID %d: %w", post.AuthorID, storage.ErrAuthorNotFound)
 	}
 
-	_, err = s.db.Exec(context.Background(),
-		`INSERT INTO posts (title, content, author_id, created_at) VALUES ($1, $2, $3, $4)`,
-		post.Title, post.Content, post.AuthorID, time.Now().Unix())
+	now := time.Now().Unix()
+	status := post.Status
+	if status == "" {
+		status = storage.PublicationStatus(post.PublishedAt, now)
+	}
 
+	var id int
+	err = tx.QueryRow(ctx,
+		`INSERT INTO posts (title, content, author_id, status, created_at, updated_at, published_at) VALUES ($1, $2, $3, $4, $5, $5, $6) RETURNING id`,
+		post.Title, post.Content, post.AuthorID, status, now, post.PublishedAt).Scan(&id)
+
+	// Автор мог быть удалён между проверкой и вставкой
+	if pgCode(err) == foreignKeyViolation {
+		return fmt.Errorf("автор с ID %d: %w", post.AuthorID, storage.ErrAuthorNotFound)
+	}
 	if err != nil {
 		return fmt.Errorf("ошибка при добавлении поста: %w", err)
 	}
 
-	return nil
+	if err := addRevision(ctx, tx, id); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
-// UpdatePost обновляет существующую публикацию в базе данных.
-func (s *Store) UpdatePost(post storage.Post) error {
+// UpdatePost обновляет существующую публикацию в базе данных
+// и сохраняет её новое состояние как ревизию.
+// Состояние и момент публикации меняются только через PublishPost.
+func (s *Store) UpdatePost(ctx context.Context, post storage.Post) error {
 	// Сначала создаем переменную для создания запроса
 	query := `UPDATE posts SET`
 	var args []interface{}
@@ -142,32 +246,119 @@ func (s *Store) UpdatePost(post storage.Post) error {
 
 	// Если не указаны поля для обновления, возвращаем ошибку
 	if len(setClauses) == 0 {
-		return fmt.Errorf("нет полей для обновления")
+		return fmt.Errorf("нет полей для обновления: %w", storage.ErrInvalid)
 	}
 
+	setClauses = append(setClauses, fmt.Sprintf(" updated_at = $%d", len(args)+1), " version = version + 1")
+	args = append(args, time.Now().Unix())
+
 	// Добавляем условие WHERE к запросу
-	query += fmt.Sprintf("%s WHERE id = $%d",
+	query += fmt.Sprintf("%s WHERE id = $%d AND deleted_at = 0",
 		strings.Join(setClauses, ","),
 		len(args)+1)
 
 	// Добавляем ID поста в аргументы
 	args = append(args, post.ID)
 
+	// Изменяем только указанную версию публикации
+	if post.Version != 0 {
+		args = append(args, post.Version)
+		query += fmt.Sprintf(" AND version = $%d", len(args))
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	// Выполняем запрос
-	_, err := s.db.Exec(context.Background(), query, args...)
+	tag, err := tx.Exec(ctx, query, args...)
+	if pgCode(err) == foreignKeyViolation {
+		return fmt.Errorf("автор с ID %d: %w", post.AuthorID, storage.ErrAuthorNotFound)
+	}
 	if err != nil {
 		return fmt.Errorf("ошибка при обновлении поста: %w", err)
 	}
+	if tag.RowsAffected() == 0 {
+		return notUpdated(ctx, tx, post.ID)
+	}
 
-	return nil
+	if err := addRevision(ctx, tx, post.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
-// DeletePost удаляет публикацию из базы данных.
-func (s *Store) DeletePost(post storage.Post) error {
-	_, err := s.db.Exec(context.Background(), `DELETE FROM posts WHERE id = $1`, post.ID)
+// DeletePost перемещает публикацию в корзину.
+func (s *Store) DeletePost(ctx context.Context, post storage.Post) error {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE posts SET deleted_at = $1, updated_at = $1, version = version + 1
+		WHERE id = $2 AND deleted_at = 0 AND ($3 = 0 OR version = $3)`,
+		time.Now().Unix(), post.ID, post.Version)
 	if err != nil {
 		return fmt.Errorf("ошибка при удалении поста: %w", err)
 	}
+	if tag.RowsAffected() == 0 {
+		return notUpdated(ctx, s.db, post.ID)
+	}
+
+	return nil
+}
+
+// PublishPost публикует публикацию в момент at: если он уже наступил,
+// публикация становится опубликованной, иначе - запланированной.
+func (s *Store) PublishPost(ctx context.Context, id int, at int64) error {
+	now := time.Now().Unix()
+	status := storage.PublicationStatus(at, now)
+	tag, err := s.db.Exec(ctx, `UPDATE posts SET status = $1, published_at = $2, updated_at = $4, version = version + 1 WHERE id = $3 AND deleted_at = 0`, status, at, id, now)
+	if err != nil {
+		return fmt.Errorf("ошибка при публикации поста: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("публикация с ID %d: %w", id, storage.ErrNotFound)
+	}
 
 	return nil
 }
+
+// PublishDue публикует запланированные публикации, момент публикации
+// которых не позже now, и возвращает их.
+func (s *Store) PublishDue(ctx context.Context, now int64) ([]storage.Post, error) {
+	rows, err := s.db.Query(ctx, `
+		UPDATE posts p SET status = $1, updated_at = $3, version = p.version + 1
+		FROM authors a
+		WHERE p.author_id = a.id AND p.status = $2 AND p.published_at <= $3 AND p.deleted_at = 0
+		RETURNING `+postColumns,
+		storage.StatusPublished, storage.StatusScheduled, now)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка публикации запланированных постов: %w", err)
+	}
+
+	return scanPosts(rows)
+}
+
+// RestorePost восстанавливает публикацию из корзины.
+func (s *Store) RestorePost(ctx context.Context, id int) error {
+	tag, err := s.db.Exec(ctx, `UPDATE posts SET deleted_at = 0, updated_at = $2, version = version + 1 WHERE id = $1 AND deleted_at <> 0`, id, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("ошибка при восстановлении поста: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("публикация с ID %d в корзине: %w", id, storage.ErrNotFound)
+	}
+
+	return nil
+}
+
+// PurgePosts окончательно удаляет публикации, перемещённые в корзину
+// раньше before, и возвращает их количество.
+func (s *Store) PurgePosts(ctx context.Context, before int64) (int, error) {
+	tag, err := s.db.Exec(ctx, `DELETE FROM posts WHERE deleted_at <> 0 AND deleted_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при очистке корзины: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
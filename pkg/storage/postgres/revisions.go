@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"GoNews/pkg/storage"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// revisionColumns - список полей ревизии.
+const revisionColumns = `post_id, number, title, content, author_id, author_name, editor, created_at`
+
+// addRevision сохраняет текущее состояние публикации как очередную ревизию.
+// Вызывается в транзакции, изменившей публикацию: строка публикации уже
+// заблокирована, поэтому номера ревизий не пересекаются.
+func addRevision(ctx context.Context, tx pgx.Tx, postID int) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO post_revisions (`+revisionColumns+`)
+		SELECT p.id,
+			COALESCE((SELECT max(number) FROM post_revisions WHERE post_id = p.id), 0) + 1,
+			p.title, p.content, p.author_id, a.name, $2, $3
+		`+postsFrom+`
+		WHERE p.id = $1`,
+		postID, storage.EditorFrom(ctx), time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении ревизии: %w", err)
+	}
+
+	return nil
+}
+
+// scanRevision читает ревизию из строки, выбранной по revisionColumns.
+func scanRevision(row pgx.Row) (storage.Revision, error) {
+	var r storage.Revision
+	err := row.Scan(&r.PostID, &r.Number, &r.Title, &r.Content, &r.AuthorID, &r.AuthorName, &r.Editor, &r.CreatedAt)
+	return r, err
+}
+
+// Revisions возвращает все ревизии публикации по возрастанию номера.
+func (s *Store) Revisions(ctx context.Context, postID int) ([]storage.Revision, error) {
+	rows, err := s.db.Query(ctx,
+		`SELECT `+revisionColumns+` FROM post_revisions WHERE post_id = $1 ORDER BY number`, postID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer rows.Close()
+
+	var revs []storage.Revision
+	for rows.Next() {
+		r, err := scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения строки: %w", err)
+		}
+		revs = append(revs, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	if len(revs) > 0 {
+		return revs, nil
+	}
+
+	// Ревизий нет: публикации либо нет, либо она добавлена в обход хранилища.
+	var exists bool
+	err = s.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM posts WHERE id = $1)`, postID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("публикация с ID %d: %w", postID, storage.ErrNotFound)
+	}
+
+	return []storage.Revision{}, nil
+}
+
+// Revision возвращает ревизию публикации по номеру.
+func (s *Store) Revision(ctx context.Context, postID, number int) (storage.Revision, error) {
+	row := s.db.QueryRow(ctx,
+		`SELECT `+revisionColumns+` FROM post_revisions WHERE post_id = $1 AND number = $2`, postID, number)
+	r, err := scanRevision(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return storage.Revision{}, fmt.Errorf("ревизия %d публикации с ID %d: %w", number, postID, storage.ErrNotFound)
+	}
+	if err != nil {
+		return storage.Revision{}, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+
+	return r, nil
+}
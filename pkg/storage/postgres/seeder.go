@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"GoNews/pkg/storage"
+	"context"
+	"fmt"
+	"time"
+)
+
+// SeedPosts заполняет пустую базу демонстрационными автором и публикацией.
+// Если в базе уже есть авторы, ничего не делает: повторный запуск
+// с флагом -seed не создаёт дубликатов.
+func SeedPosts(ctx context.Context, s *Store) error {
+	var exists bool
+	if err := s.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM authors)`).Scan(&exists); err != nil {
+		return fmt.Errorf("ошибка при проверке авторов: %w", err)
+	}
+	if exists {
+		fmt.Println("В базе уже есть авторы, сидирование пропущено.")
+		return nil
+	}
+
+	authorID, err := s.AddAuthor(ctx, storage.Author{Name: "Дмитрий"})
+	if err != nil {
+		return fmt.Errorf("ошибка при добавлении автора: %w", err)
+	}
+	err = s.AddPost(ctx, storage.Post{
+		Title:       "Статья",
+		Content:     "Содержание статьи",
+		AuthorID:    authorID,
+		PublishedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка при добавлении публикации: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"GoNews/pkg/storage"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// userColumns - столбцы пользователя в порядке полей storage.User.
+// Имя пользователя - имя связанного автора.
+const userColumns = `u.id, u.email, COALESCE(a.name, ''), u.password_hash, u.role,
+	COALESCE(u.author_id, 0), u.created_at, u.reset_token_hash, u.reset_expires_at, u.token_version,
+	u.oidc_issuer, u.oidc_subject`
+
+// userByCondition возвращает пользователя, удовлетворяющего условию cond с параметрами args.
+func (s *Store) userByCondition(ctx context.Context, cond string, args ...interface{}) (storage.User, error) {
+	var u storage.User
+	err := s.db.QueryRow(ctx, `
+		SELECT `+userColumns+`
+		FROM users u LEFT JOIN authors a ON u.author_id = a.id
+		WHERE `+cond, args...).Scan(
+		&u.ID, &u.Email, &u.Name, &u.PasswordHash, &u.Role,
+		&u.AuthorID, &u.CreatedAt, &u.ResetTokenHash, &u.ResetExpiresAt, &u.TokenVersion,
+		&u.OIDCIssuer, &u.OIDCSubject)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return storage.User{}, fmt.Errorf("пользователь: %w", storage.ErrNotFound)
+	}
+	if err != nil {
+		return storage.User{}, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+
+	return u, nil
+}
+
+// AddUser создаёт пользователя и связанного с ним автора u.Name в одной транзакции.
+func (s *Store) AddUser(ctx context.Context, u storage.User) (storage.User, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return storage.User{}, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Имена авторов не уникальны, поэтому конфликт возможен только по адресу.
+	err = tx.QueryRow(ctx, `INSERT INTO authors (name) VALUES ($1) RETURNING id`, u.Name).Scan(&u.AuthorID)
+	if err != nil {
+		return storage.User{}, fmt.Errorf("ошибка при добавлении автора: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO users (email, password_hash, role, author_id, created_at, oidc_issuer, oidc_subject)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		u.Email, u.PasswordHash, u.Role, u.AuthorID, u.CreatedAt, u.OIDCIssuer, u.OIDCSubject).Scan(&u.ID)
+	if pgCode(err) == uniqueViolation {
+		return storage.User{}, fmt.Errorf("пользователь %s или его учётная запись OIDC уже существует: %w", u.Email, storage.ErrConflict)
+	}
+	if err != nil {
+		return storage.User{}, fmt.Errorf("ошибка при добавлении пользователя: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return storage.User{}, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	return u, nil
+}
+
+// User возвращает пользователя по ID.
+func (s *Store) User(ctx context.Context, id int) (storage.User, error) {
+	return s.userByCondition(ctx, `u.id = $1`, id)
+}
+
+// UserByEmail возвращает пользователя по адресу электронной почты.
+func (s *Store) UserByEmail(ctx context.Context, email string) (storage.User, error) {
+	return s.userByCondition(ctx, `u.email = $1`, email)
+}
+
+// UserByResetToken возвращает пользователя по хешу токена сброса пароля.
+func (s *Store) UserByResetToken(ctx context.Context, hash string) (storage.User, error) {
+	if hash == "" {
+		return storage.User{}, fmt.Errorf("пользователь: %w", storage.ErrNotFound)
+	}
+	return s.userByCondition(ctx, `u.reset_token_hash = $1`, hash)
+}
+
+// UserByOIDC возвращает пользователя, привязанного к учётной записи subject
+// провайдера OpenID Connect issuer.
+func (s *Store) UserByOIDC(ctx context.Context, issuer, subject string) (storage.User, error) {
+	if subject == "" {
+		return storage.User{}, fmt.Errorf("пользователь: %w", storage.ErrNotFound)
+	}
+	return s.userByCondition(ctx, `u.oidc_subject = $1 AND u.oidc_issuer = $2`, subject, issuer)
+}
+
+// LinkOIDC привязывает к пользователю учётную запись провайдера OpenID Connect.
+func (s *Store) LinkOIDC(ctx context.Context, id int, issuer, subject string) error {
+	err := s.updateUser(ctx, id, `
+		UPDATE users SET oidc_issuer = $2, oidc_subject = $3
+		WHERE id = $1`, issuer, subject)
+	if pgCode(err) == uniqueViolation {
+		return fmt.Errorf("учётная запись OIDC уже привязана: %w", storage.ErrConflict)
+	}
+	return err
+}
+
+// SetPassword меняет хеш пароля пользователя, аннулирует токен сброса
+// и увеличивает версию токенов пользователя.
+func (s *Store) SetPassword(ctx context.Context, id int, hash string) error {
+	return s.updateUser(ctx, id, `
+		UPDATE users SET password_hash = $2, reset_token_hash = '', reset_expires_at = 0,
+			token_version = token_version + 1
+		WHERE id = $1`, hash)
+}
+
+// SetResetToken сохраняет хеш токена сброса пароля и момент его истечения.
+func (s *Store) SetResetToken(ctx context.Context, id int, hash string, expiresAt int64) error {
+	return s.updateUser(ctx, id, `
+		UPDATE users SET reset_token_hash = $2, reset_expires_at = $3
+		WHERE id = $1`, hash, expiresAt)
+}
+
+// SetUserRole меняет роль пользователя и увеличивает версию его токенов.
+func (s *Store) SetUserRole(ctx context.Context, id int, role string) error {
+	return s.updateUser(ctx, id, `
+		UPDATE users SET role = $2, token_version = token_version + 1
+		WHERE id = $1`, role)
+}
+
+// updateUser выполняет изменение пользователя с ID id.
+func (s *Store) updateUser(ctx context.Context, id int, sql string, args ...interface{}) error {
+	tag, err := s.db.Exec(ctx, sql, append([]interface{}{id}, args...)...)
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении пользователя: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("пользователь с ID %d: %w", id, storage.ErrNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Границы выделения найденных слов во фрагменте текста.
+// Фрагмент является HTML: текст публикации в нём экранирован.
+const (
+	HighlightStart = "<b>"
+	HighlightStop  = "</b>"
+)
+
+// snippetWords - количество слов во фрагменте текста.
+const snippetWords = 30
+
+// Terms разбивает текст на слова в нижнем регистре.
+// Словом считается последовательность букв и цифр.
+func Terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Snippet возвращает фрагмент текста публикации вокруг первого найденного
+// слова запроса, выделяя найденные слова маркерами HighlightStart и HighlightStop.
+// Фрагмент берётся из текста, а если слова запроса встречаются только
+// в заголовке - из заголовка. Если слов запроса нет ни там, ни там,
+// возвращается начало текста.
+// Текст публикации экранируется, поэтому фрагмент можно выводить как HTML.
+func Snippet(title, content string, terms []string) string {
+	want := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		want[t] = true
+	}
+	matched := func(w string) bool {
+		for _, t := range Terms(w) {
+			if want[t] {
+				return true
+			}
+		}
+		return false
+	}
+
+	words := strings.Fields(content)
+	first := -1
+	for i, w := range words {
+		if matched(w) {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		for _, w := range strings.Fields(title) {
+			if matched(w) {
+				return snippet(strings.Fields(title), 0, matched)
+			}
+		}
+		first = 0
+	}
+
+	return snippet(words, first, matched)
+}
+
+// snippet собирает фрагмент из snippetWords слов вокруг слова first.
+func snippet(words []string, first int, matched func(string) bool) string {
+	start := first - snippetWords/3
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetWords
+	if end > len(words) {
+		end = len(words)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("… ")
+	}
+	for i := start; i < end; i++ {
+		if i > start {
+			b.WriteByte(' ')
+		}
+		if matched(words[i]) {
+			b.WriteString(HighlightStart + html.EscapeString(words[i]) + HighlightStop)
+		} else {
+			b.WriteString(html.EscapeString(words[i]))
+		}
+	}
+	if end < len(words) {
+		b.WriteString(" …")
+	}
+
+	return b.String()
+}
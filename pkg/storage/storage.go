@@ -1,5 +1,53 @@
 package storage
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Ошибки, которые возвращают реализации хранилища.
+// Проверять их следует через errors.Is: хранилища добавляют к ним подробности.
+var (
+	// ErrNotFound - запрошенный объект отсутствует в хранилище.
+	ErrNotFound = errors.New("объект не найден")
+	// ErrAuthorNotFound - публикация ссылается на несуществующего автора.
+	ErrAuthorNotFound = errors.New("автор не существует")
+	// ErrConflict - операция противоречит текущему состоянию данных.
+	ErrConflict = errors.New("конфликт с текущим состоянием данных")
+	// ErrInvalid - переданные данные не могут быть сохранены.
+	ErrInvalid = errors.New("некорректные данные")
+)
+
+// ErrAuthorHasPosts возвращается при удалении автора, у которого есть
+// публикации, если не запрошено их каскадное удаление.
+var ErrAuthorHasPosts = fmt.Errorf("у автора есть публикации: %w", ErrConflict)
+
+// ErrStale возвращается при изменении публикации, если указанная версия
+// не совпадает с текущей: публикацию уже изменил кто-то другой.
+var ErrStale = fmt.Errorf("версия публикации устарела: %w", ErrConflict)
+
+// Состояния публикации.
+const (
+	StatusDraft     = "draft"     // черновик, виден только редакции
+	StatusScheduled = "scheduled" // будет опубликована в момент PublishedAt
+	StatusPublished = "published" // опубликована и видна всем
+)
+
+// PublicationStatus возвращает состояние публикации с моментом
+// публикации at относительно текущего момента now.
+func PublicationStatus(at, now int64) string {
+	switch {
+	case at == 0:
+		return StatusDraft
+	case at > now:
+		return StatusScheduled
+	default:
+		return StatusPublished
+	}
+}
+
 // Post - публикация.
 type Post struct {
 	ID          int
@@ -7,14 +55,181 @@ type Post struct {
 	Content     string
 	AuthorID    int
 	AuthorName  string
+	Status      string
 	CreatedAt   int64
 	PublishedAt int64
+	UpdatedAt   int64 // момент последнего изменения публикации
+	DeletedAt   int64 // момент перемещения в корзину, 0 - публикация не удалена
+	Version     int   // номер версии, увеличивается при каждом изменении публикации
+}
+
+// Author - автор публикаций.
+type Author struct {
+	ID   int
+	Name string
+}
+
+// Поля, по которым можно сортировать публикации.
+const (
+	SortByID          = "id"
+	SortByCreatedAt   = "created_at"
+	SortByPublishedAt = "published_at"
+	SortByTitle       = "title"
+	SortByDeletedAt   = "deleted_at"
+)
+
+// PostFilter - условия отбора и порядок сортировки публикаций.
+// Нулевые значения полей означают отсутствие ограничения.
+type PostFilter struct {
+	AuthorID       int    // ID автора
+	CreatedAfter   int64  // создана не раньше указанного момента (Unix)
+	CreatedBefore  int64  // создана раньше указанного момента (Unix)
+	Status         string // состояние публикации
+	PublishedAfter int64  // опубликована не раньше указанного момента (Unix)
+	TitleContains  string // подстрока заголовка без учёта регистра
+	Deleted        bool   // отбирать публикации из корзины вместо остальных
+	SortBy         string // поле сортировки, по умолчанию SortByID
+	SortDesc       bool   // сортировка по убыванию
+}
+
+// Cursor - позиция в ленте публикаций, упорядоченной по убыванию
+// (CreatedAt, ID). Используется для постраничного чтения без смещений.
+type Cursor struct {
+	CreatedAt int64
+	ID        int
+}
+
+// SearchResult - публикация, найденная полнотекстовым поиском,
+// с оценкой релевантности и фрагментом текста с найденными словами, см. Snippet.
+type SearchResult struct {
+	Post
+	Rank    float64
+	Snippet string
+}
+
+// Revision - сохранённое состояние публикации после создания или изменения.
+type Revision struct {
+	PostID     int
+	Number     int // порядковый номер ревизии публикации, начиная с 1
+	Title      string
+	Content    string
+	AuthorID   int
+	AuthorName string
+	Editor     string // кто внёс изменение, см. WithEditor
+	CreatedAt  int64
+}
+
+// APIKey - ключ доступа к API для автоматических клиентов.
+// Хранится только хеш ключа: сам ключ показывается один раз при выпуске.
+type APIKey struct {
+	ID        int
+	Name      string   // описание владельца ключа
+	Hash      string   // SHA-256 ключа в шестнадцатеричном виде
+	Scopes    []string // разрешения ключа
+	Role      string   // роль клиента с этим ключом
+	AuthorID  int      // ID автора, от имени которого действует ключ, 0 - не автор
+	CreatedAt int64
+	RevokedAt int64 // момент отзыва, 0 - ключ действует
+}
+
+// User - учётная запись пользователя. Каждый пользователь связан с автором,
+// от имени которого пишет публикации.
+type User struct {
+	ID             int
+	Email          string // адрес электронной почты в нижнем регистре, используется для входа
+	Name           string // имя автора, создаваемого при регистрации
+	PasswordHash   string // хеш пароля bcrypt
+	Role           string // роль пользователя, см. пакет auth
+	AuthorID       int    // ID связанного автора, 0 - автор удалён
+	CreatedAt      int64
+	ResetTokenHash string // SHA-256 токена сброса пароля, пустая строка - сброс не запрошен
+	ResetExpiresAt int64  // момент истечения токена сброса пароля
+	TokenVersion   int    // увеличивается при смене пароля и роли, токены с прежней версией недействительны
+	OIDCIssuer     string // издатель OpenID Connect, через которого входит пользователь, пустой - вход через OIDC не привязан
+	OIDCSubject    string // постоянный идентификатор пользователя у издателя OIDCIssuer (поле sub ID-токена)
+}
+
+// editorKey - ключ контекста с именем редактора.
+type editorKey struct{}
+
+// WithEditor возвращает контекст с именем редактора, которое хранилище
+// записывает в ревизии, создаваемые AddPost и UpdatePost.
+func WithEditor(ctx context.Context, editor string) context.Context {
+	return context.WithValue(ctx, editorKey{}, editor)
+}
+
+// EditorFrom возвращает имя редактора из контекста или пустую строку.
+func EditorFrom(ctx context.Context) string {
+	editor, _ := ctx.Value(editorKey{}).(string)
+	return editor
+}
+
+// AuthorRepository задаёт контракт на работу с авторами.
+type AuthorRepository interface {
+	Authors(ctx context.Context) ([]Author, error)                // получение всех авторов
+	Author(ctx context.Context, id int) (Author, error)           // получение автора по ID
+	AddAuthor(ctx context.Context, a Author) (int, error)         // создание автора, возвращает его ID
+	UpdateAuthor(ctx context.Context, a Author) error             // переименование автора
+	DeleteAuthor(ctx context.Context, id int, cascade bool) error // удаление автора, при cascade - вместе с публикациями
+}
+
+// RevisionRepository задаёт контракт на чтение истории изменений публикаций.
+// Ревизии создаются хранилищем при каждом вызове AddPost и UpdatePost.
+// Для несуществующей публикации возвращается ErrNotFound.
+type RevisionRepository interface {
+	Revisions(ctx context.Context, postID int) ([]Revision, error)      // все ревизии публикации по возрастанию номера
+	Revision(ctx context.Context, postID, number int) (Revision, error) // ревизия публикации по номеру
+}
+
+// APIKeyRepository задаёт контракт на работу с ключами API.
+type APIKeyRepository interface {
+	APIKeys(ctx context.Context) ([]APIKey, error)                 // все ключи, упорядоченные по ID
+	APIKeyByHash(ctx context.Context, hash string) (APIKey, error) // ключ по хешу, в том числе отозванный
+	AddAPIKey(ctx context.Context, k APIKey) (int, error)          // сохранение ключа, возвращает его ID
+	RevokeAPIKey(ctx context.Context, id int, at int64) error      // отзыв ключа; повторный отзыв не меняет момент отзыва
+}
+
+// UserRepository задаёт контракт на работу с учётными записями пользователей.
+type UserRepository interface {
+	AddUser(ctx context.Context, u User) (User, error)                             // создание пользователя вместе с автором u.Name; ErrConflict, если адрес или учётная запись OIDC заняты
+	User(ctx context.Context, id int) (User, error)                                // пользователь по ID
+	UserByEmail(ctx context.Context, email string) (User, error)                   // пользователь по адресу электронной почты
+	UserByResetToken(ctx context.Context, hash string) (User, error)               // пользователь по хешу токена сброса пароля
+	UserByOIDC(ctx context.Context, issuer, subject string) (User, error)          // пользователь, привязанный к учётной записи провайдера OpenID Connect
+	LinkOIDC(ctx context.Context, id int, issuer, subject string) error            // привязка учётной записи провайдера; ErrConflict, если она привязана к другому пользователю
+	SetPassword(ctx context.Context, id int, hash string) error                    // смена хеша пароля, токен сброса аннулируется, TokenVersion увеличивается
+	SetResetToken(ctx context.Context, id int, hash string, expiresAt int64) error // сохранение хеша токена сброса пароля
+	SetUserRole(ctx context.Context, id int, role string) error                    // смена роли пользователя, TokenVersion увеличивается
+}
+
+// Locker задаёт блокировку, разделяемую всеми экземплярами сервера,
+// работающими с одной БД. Она позволяет выполнять фоновые задачи
+// только на одном экземпляре.
+type Locker interface {
+	// TryLock пытается захватить блокировку name без ожидания. Если блокировка
+	// занята, возвращает ok == false. Захваченная блокировка освобождается
+	// вызовом unlock или по истечении ttl, если экземпляр перестал работать.
+	TryLock(ctx context.Context, name string, ttl time.Duration) (unlock func(), ok bool, err error)
 }
 
 // Interface задаёт контракт на работу с БД.
+// Все методы принимают контекст запроса и прекращают работу при его отмене.
 type Interface interface {
-	Posts() ([]Post, error) // получение всех публикаций
-	AddPost(Post) error     // создание новой публикации
-	UpdatePost(Post) error  // обновление публикации
-	DeletePost(Post) error  // удаление публикации по ID
+	AuthorRepository
+	RevisionRepository
+	APIKeyRepository
+	UserRepository
+
+	Posts(ctx context.Context, f PostFilter, limit, offset int) ([]Post, int, error)                   // получение страницы публикаций и их общего количества
+	PostsAfter(ctx context.Context, f PostFilter, cursor *Cursor, limit int) ([]Post, error)           // получение публикаций, следующих за курсором
+	Post(ctx context.Context, id int) (Post, error)                                                    // получение публикации по ID
+	Search(ctx context.Context, f PostFilter, query string, limit, offset int) ([]SearchResult, error) // полнотекстовый поиск публикаций
+	AddPost(ctx context.Context, p Post) error                                                         // создание новой публикации
+	UpdatePost(ctx context.Context, p Post) error                                                      // обновление публикации; при p.Version != 0 - только этой версии
+	DeletePost(ctx context.Context, p Post) error                                                      // перемещение публикации в корзину по ID; при p.Version != 0 - только этой версии
+	RestorePost(ctx context.Context, id int) error                                                     // восстановление публикации из корзины
+	PurgePosts(ctx context.Context, before int64) (int, error)                                         // окончательное удаление публикаций, перемещённых в корзину до before
+	PublishPost(ctx context.Context, id int, at int64) error                                           // публикация в момент at: сразу или по расписанию
+	PublishDue(ctx context.Context, now int64) ([]Post, error)                                         // публикация запланированных публикаций, срок которых наступил
+	Locker
 }